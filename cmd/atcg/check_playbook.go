@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// checkPlaybookRules are the lint rules check-playbook reports.
+var checkPlaybookRules = []string{"args", "deprecated", "docs", "load-failure"}
+
+// runCheckPlaybook implements the check-playbook subcommand.
+func runCheckPlaybook(args []string) error {
+	var format string
+	var skip []string
+	var noFollow, strict bool
+	var common commonFlags
+
+	fs := newFlagSet("check-playbook", "atcg check-playbook [flags] <path>...", "Check the literal parameters of every module call in playbooks, roles and task\nfiles against the module documentation: unknown and missing options, invalid\nchoices and deprecations. Directories are searched for .yml and .yaml files,\nand imported playbooks, included task files and roles are followed.\nExits non-zero when there are errors, or any findings with --strict.")
+	fs.StringVarP(&format, "format", "f", "text", fmt.Sprintf("Report format (%s)", strings.Join(atcgLint.Formats, ", ")))
+	fs.StringSliceVar(&skip, "skip", nil, "Rule to skip, e.g. args[deprecated] (can be used multiple times)")
+	fs.BoolVar(&noFollow, "no-follow", false, "Do not follow imports, includes and roles")
+	fs.BoolVar(&strict, "strict", false, "Exit non-zero on warnings too")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no playbooks specified")
+	}
+	format, err := atcgLint.Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+
+	_, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	linter := &atcgLint.Linter{
+		Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+			return atcgModules.ParseModuleDoc(executor, module)
+		},
+		Routing: atcgModules.LazyRouting(executor),
+		Skip:    skip,
+		Only:    checkPlaybookRules,
+	}
+
+	queue, err := findFiles(fs.Args(), ".yml", ".yaml")
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	var findings []atcgLint.Finding
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, linter.LintRole(file, content, roleCollections(file))...)
+		if noFollow {
+			continue
+		}
+		referenced, err := findFiles(atcgLint.References(file, content), ".yml", ".yaml")
+		if err != nil {
+			return err
+		}
+		queue = append(queue, referenced...)
+	}
+
+	if err := atcgLint.Write(os.Stdout, format, findings, version); err != nil {
+		return err
+	}
+	return lintResult(findings, len(seen), strict)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	atcgDiff "atcg/internal/atcg/diff"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// runDiff implements the diff subcommand.
+func runDiff(args []string) error {
+	var output string
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("diff", "atcg diff [flags]", "Show the changes generate would make to the output directory.\nExits non-zero when there are differences.")
+	mods.register(fs)
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory to compare against")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules specified, use -m or the config file")
+	}
+	dir := outputDir(fs, output, cfg)
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+
+	changed := false
+	compare := func(name, content string) error {
+		path, err := atcgTasks.OutputPath(dir, name)
+		if err != nil {
+			return err
+		}
+		oldName := filepath.ToSlash(filepath.Join("a", path))
+		existing, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if err != nil {
+			return err
+		}
+		if out := atcgDiff.Unified(oldName, filepath.ToSlash(filepath.Join("b", path)), string(existing), content); out != "" {
+			changed = true
+			fmt.Print(out)
+		}
+		return nil
+	}
+
+	var moduleDetails []atcgTasks.Module
+	for _, module := range modules {
+		task, details, err := atcgTasks.RenderModule(module, executor, opts)
+		if err != nil {
+			return err
+		}
+		if err := compare(details.Path, task); err != nil {
+			return err
+		}
+		moduleDetails = append(moduleDetails, *details)
+	}
+
+	mains, err := atcgTasks.RenderMains(moduleDetails, opts)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(mains))
+	for name := range mains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := compare(name, mains[name]); err != nil {
+			return err
+		}
+	}
+
+	if changed {
+		return fmt.Errorf("generated files differ from %s", dir)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	atcgDoctor "atcg/internal/atcg/doctor"
+)
+
+// runDoctor implements the doctor subcommand.
+func runDoctor(args []string) error {
+	var common commonFlags
+	var output, format string
+
+	fs := newFlagSet("doctor", "atcg doctor [flags]", "Check the environment atcg depends on.")
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory to check")
+	fs.StringVar(&format, "format", "text", "Report format: text or json")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+
+	build := atcgDoctor.BuildInfo{Version: version, Commit: commit, BuildDate: buildDate}
+	report := atcgDoctor.Run(executor, build, atcgDoctor.Options{OutputDir: outputDir(fs, output, cfg), CacheDir: common.cacheDir})
+
+	switch format {
+	case "text":
+		err = report.WriteText(os.Stdout)
+	case "json":
+		err = report.WriteJSON(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format %q, expected text or json", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("doctor found problems")
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	atcgMigrate "atcg/internal/atcg/migrate"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// runExtract implements the extract subcommand.
+func runExtract(args []string) error {
+	var output, varsFile string
+	var min int
+	var replace, force bool
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("extract", "atcg extract [flags] [path...]", "Find runs of adjacent tasks calling the same module in playbooks, roles and task\nfiles, generate the task file and main.yml for the module, and write a vars\nfile with one loop item per original task. With --replace, each run is\nreplaced by an include of the generated task file.")
+	mods.register(fs)
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory for generated tasks")
+	fs.StringVar(&varsFile, "vars", "extracted.yml", "Vars file to write the loop items to")
+	fs.IntVar(&min, "min", 2, "Extract runs of at least this many tasks")
+	fs.BoolVar(&replace, "replace", false, "Replace the extracted tasks with an include of the generated task file")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing vars file")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if min < 1 {
+		return fmt.Errorf("--min must be at least 1")
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if !force {
+		if _, err := os.Stat(varsFile); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", varsFile)
+		}
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	collections, err := mods.searchList(cfg)
+	if err != nil {
+		return err
+	}
+	var selected []string
+	if fs.Changed("module") {
+		if selected, err = mods.resolve(cfg, executor); err != nil {
+			return err
+		}
+	}
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts.Loop == atcgTasks.LoopSingle {
+		return fmt.Errorf("tasks cannot be extracted with --loop single, which has no items")
+	}
+
+	lookup := newFQCNLookup(executor)
+	extractor := &atcgMigrate.Extractor{
+		Lookup: lookup.lookup,
+		Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+			return atcgModules.ParseModuleDoc(executor, module)
+		},
+		Keywords: opts.Keywords,
+		Modules:  selected,
+		Min:      min,
+	}
+	files, err := scanFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	var report atcgMigrate.Report
+	var modules []string
+	byModule := make(map[string][]atcgMigrate.Run)
+	byFile := make(map[string][]atcgMigrate.Run)
+	contents := make(map[string][]byte)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		searched := roleCollections(file)
+		if searched == nil {
+			searched = collections
+		}
+		runs, skipped := extractor.Runs(file, content, searched)
+		report.Ambiguities = append(report.Ambiguities, skipped...)
+		for _, run := range runs {
+			if byModule[run.Module] == nil {
+				modules = append(modules, run.Module)
+			}
+			byModule[run.Module] = append(byModule[run.Module], run)
+			byFile[file] = append(byFile[file], run)
+			contents[file] = content
+		}
+	}
+	if err := atcgMigrate.WriteReport(os.Stderr, "text", report); err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no runs of at least %d tasks calling the same module found in %v", min, paths)
+	}
+
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+	dir := outputDir(fs, output, cfg)
+	if err := atcgTasks.CheckOutputs(dir, opts.Outputs(modules), files); err != nil {
+		return err
+	}
+	variables := make([]atcgMigrate.Variable, 0, len(modules))
+	details := make(map[string]*atcgTasks.Module, len(modules))
+	for _, module := range modules {
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err != nil {
+			return err
+		}
+		inputs := opts.Inputs(module, doc)
+		items, err := atcgMigrate.Items(byModule[module], inputs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", module, err)
+		}
+		variables = append(variables, atcgMigrate.Variable{
+			Name:    inputs.Variable,
+			Value:   items,
+			Comment: fmt.Sprintf("Loop items for %s, extracted by atcg.", module),
+		})
+
+		if !replace {
+			continue
+		}
+		if runs := byModule[module]; len(runs) > 1 {
+			var places []string
+			for _, run := range runs {
+				places = append(places, fmt.Sprintf("%s:%d", run.File, run.Invocations[0].Line))
+			}
+			return fmt.Errorf("%s has %d runs (%s), but --replace needs a single run per module; narrow the paths or use -m", module, len(runs), strings.Join(places, ", "))
+		}
+		if _, details[module], err = atcgTasks.RenderModule(module, executor, opts); err != nil {
+			return err
+		}
+	}
+	vars, err := atcgMigrate.EncodeVars(variables)
+	if err != nil {
+		return err
+	}
+
+	// Nothing is written before every run is known to be replaceable.
+	replaced := make(map[string][]byte, len(byFile))
+	if replace {
+		for file, runs := range byFile {
+			out, err := atcgMigrate.Replace(contents[file], runs, func(run atcgMigrate.Run) (string, error) {
+				module := *details[run.Module]
+				taskFile, err := atcgTasks.OutputPath(dir, module.Path)
+				if err != nil {
+					return "", err
+				}
+				rel, err := filepath.Rel(filepath.Dir(file), taskFile)
+				if err != nil {
+					return "", err
+				}
+				module.Path = filepath.ToSlash(rel)
+				return atcgTasks.RenderMain([]atcgTasks.Module{module}, opts)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			replaced[file] = out
+		}
+	}
+
+	if err := Run(modules, dir, executor, opts); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(varsFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(varsFile, vars, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", varsFile, err)
+	}
+	for _, module := range modules {
+		count := 0
+		for _, run := range byModule[module] {
+			count += len(run.Invocations)
+		}
+		fmt.Printf("Extracted %d tasks calling %s to %s\n", count, module, varsFile)
+	}
+	for _, file := range files {
+		out, ok := replaced[file]
+		if !ok {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, out, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+		fmt.Printf("Replaced the extracted tasks in %s; load %s in its play\n", file, varsFile)
+	}
+	return nil
+}
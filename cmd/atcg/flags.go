@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	atcgConfig "atcg/internal/atcg/config"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+
+	"github.com/spf13/pflag"
+)
+
+// newFlagSet returns a flag set for a subcommand with its own help text.
+func newFlagSet(name, usage, description string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s\n\n%s\n\nFlags:\n%s", usage, description, fs.FlagUsages())
+	}
+	return fs
+}
+
+// parseFlags parses args into fs. A request for help is reported as errHelp
+// so that the caller can exit successfully.
+func parseFlags(fs *pflag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return errHelp
+		}
+		return err
+	}
+	return nil
+}
+
+// errHelp signals that usage was printed on request.
+var errHelp = errors.New("help requested")
+
+// commonFlags holds the flags shared by every subcommand that talks to
+// Ansible: the config file and the Ansible installation to use.
+type commonFlags struct {
+	fs              *pflag.FlagSet
+	configPath      string
+	executor        atcgModules.RealExecutor
+	collectionsPath string
+	ansibleConfig   string
+	cacheDir        string
+	noCache         bool
+}
+
+// register adds the common flags to fs.
+func (f *commonFlags) register(fs *pflag.FlagSet) {
+	f.fs = fs
+	fs.StringVarP(&f.configPath, "config", "c", atcgConfig.DefaultFile, "Configuration file (ignored if the default does not exist)")
+	fs.StringVar(&f.executor.AnsibleDoc, "ansible-doc", "", "Path to the ansible-doc binary (default: ansible-doc from PATH)")
+	fs.StringVar(&f.executor.Virtualenv, "venv", "", "Python virtualenv or pipx venv to run Ansible from")
+	fs.StringArrayVar(&f.executor.Env, "env", nil, "Extra KEY=VALUE environment variable for Ansible (can be used multiple times)")
+	fs.StringVar(&f.collectionsPath, "collections-path", "", "Shortcut for --env ANSIBLE_COLLECTIONS_PATH=...")
+	fs.StringVar(&f.ansibleConfig, "ansible-config", "", "Shortcut for --env ANSIBLE_CONFIG=...")
+	fs.StringVar(&f.executor.Dir, "workdir", "", "Working directory for ansible.cfg discovery")
+	fs.StringVar(&f.cacheDir, "cache-dir", "", "Cache ansible-doc output in this directory (default: no cache)")
+	fs.BoolVar(&f.noCache, "no-cache", false, "Do not cache ansible-doc output, even with cache_dir in the config file")
+}
+
+// config loads the configuration file. The default file is optional; one
+// named explicitly with --config must exist.
+func (f *commonFlags) config() (*atcgConfig.Config, error) {
+	if f.fs.Changed("config") {
+		return atcgConfig.Load(f.configPath)
+	}
+	return atcgConfig.LoadOptional(f.configPath)
+}
+
+// build merges the flags over the config's ansible section and returns the
+// executor they describe.
+func (f *commonFlags) build(cfg *atcgConfig.Config) (atcgModules.CommandExecutor, error) {
+	executor := f.executor
+	executor.Env = append(cfg.Ansible.EnvList(), f.executor.Env...)
+	if !f.fs.Changed("ansible-doc") {
+		executor.AnsibleDoc = cfg.Ansible.AnsibleDoc
+	}
+	if !f.fs.Changed("venv") {
+		executor.Virtualenv = cfg.Ansible.Virtualenv
+	}
+	if !f.fs.Changed("workdir") {
+		executor.Dir = cfg.Ansible.Workdir
+	}
+	// Commands run in the workdir, but paths are given from here.
+	if err := executor.Absolute(); err != nil {
+		return nil, err
+	}
+	if !f.fs.Changed("cache-dir") {
+		f.cacheDir = cfg.Ansible.CacheDir
+	}
+
+	for _, entry := range f.executor.Env {
+		if !strings.Contains(entry, "=") {
+			return nil, fmt.Errorf("invalid --env value %q, expected KEY=VALUE", entry)
+		}
+	}
+	if f.collectionsPath != "" {
+		executor.Env = append(executor.Env, "ANSIBLE_COLLECTIONS_PATH="+f.collectionsPath)
+	}
+	if f.ansibleConfig != "" {
+		executor.Env = append(executor.Env, "ANSIBLE_CONFIG="+f.ansibleConfig)
+	}
+
+	if f.cacheDir == "" || f.noCache {
+		return &atcgModules.MemoExecutor{Next: &executor}, nil
+	}
+	return &atcgModules.MemoExecutor{Next: &atcgModules.CachingExecutor{
+		Next: &executor,
+		Dir:  f.cacheDir,
+		Salt: fmt.Sprintf("%s|%s|%s|%s", executor.AnsibleDoc, executor.Virtualenv, executor.Dir, strings.Join(executor.Env, "|")),
+	}}, nil
+}
+
+// load is config followed by build.
+func (f *commonFlags) load() (*atcgConfig.Config, atcgModules.CommandExecutor, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, nil, err
+	}
+	executor, err := f.build(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, executor, nil
+}
+
+// moduleFlags holds the modules to work on and the collections searched for
+// modules given by short name.
+type moduleFlags struct {
+	fs          *pflag.FlagSet
+	modules     []string
+	collections []string
+}
+
+// register adds the module flags to fs.
+func (f *moduleFlags) register(fs *pflag.FlagSet) {
+	f.fs = fs
+	fs.StringSliceVarP(&f.modules, "module", "m", nil, "Ansible module name (can be used multiple times)")
+	fs.StringSliceVar(&f.collections, "collections", nil, "Collections to search, in order, for modules given by short name")
+}
+
+// resolve returns the modules of the flags or the config, each replaced by
+// its canonical FQCN through the collections search list, plugin routing
+// and ansible-doc. Redirects and deprecations are reported on stderr.
+// Modules that cannot be found are kept as given, so that the error shows
+// where they are used; removed modules are an error.
+func (f *moduleFlags) resolve(cfg *atcgConfig.Config, executor atcgModules.CommandExecutor) ([]string, error) {
+	modules := f.modules
+	if !f.fs.Changed("module") {
+		modules = cfg.Modules
+	}
+	for _, module := range modules {
+		if err := atcgModules.ValidateName(strings.TrimSpace(module)); err != nil {
+			return nil, err
+		}
+	}
+	collections, err := f.searchList(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(modules) == 0 {
+		return nil, nil
+	}
+
+	resolver := &atcgModules.Resolver{Exec: executor, LoadRouting: atcgModules.LazyRouting(executor), Collections: collections}
+
+	seen := make(map[string]bool, len(modules))
+	resolved := make([]string, 0, len(modules))
+	for _, module := range modules {
+		module = strings.TrimSpace(module)
+		name := module
+		result, err := resolver.Resolve(module)
+		var removed *atcgModules.RemovedError
+		if errors.As(err, &removed) {
+			return nil, err
+		}
+		if err == nil {
+			name = result.Name
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
+		if !seen[name] {
+			seen[name] = true
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved, nil
+}
+
+// searchList returns the collections of the flag or the config, which are
+// searched for modules given by short name.
+func (f *moduleFlags) searchList(cfg *atcgConfig.Config) ([]string, error) {
+	collections := f.collections
+	if !f.fs.Changed("collections") {
+		collections = cfg.Collections
+	}
+	for _, collection := range collections {
+		if err := atcgModules.ValidateCollection(collection); err != nil {
+			return nil, err
+		}
+	}
+	return collections, nil
+}
+
+// outputDir returns the --output flag if set, otherwise the configured output.
+func outputDir(fs *pflag.FlagSet, flagValue string, cfg *atcgConfig.Config) string {
+	if fs.Changed("output") {
+		return flagValue
+	}
+	return cfg.Output
+}
+
+// taskFlags holds the flags that control how task files are rendered.
+type taskFlags struct {
+	fs         *pflag.FlagSet
+	comments   bool
+	order      string
+	guards     bool
+	noLog      bool
+	loop       string
+	loopVar    string
+	label      string
+	naming     string
+	prefix     string
+	collisions string
+	layout     string
+	subMains   bool
+	keywords   []string
+}
+
+// register adds the task rendering flags to fs.
+func (f *taskFlags) register(fs *pflag.FlagSet) {
+	f.fs = fs
+	fs.BoolVar(&f.comments, "comments", false, "Add documentation comments to generated task files")
+	fs.BoolVar(&f.guards, "guards", false, "Validate loop items with mandatory filters and an assert task")
+	fs.BoolVar(&f.noLog, "no-log", false, "Set no_log on tasks with sensitive options")
+	fs.StringVar(&f.loop, "loop", "", "Loop strategy: list, dict or single")
+	fs.StringVar(&f.loopVar, "loop-var", "", "Loop variable name; {module} stands for the module basename")
+	fs.StringVar(&f.label, "label", "", "Label loop items by a key option: auto or an option name")
+	fs.StringVar(&f.naming, "naming", "", "Naming scheme: short, qualified or a template using {namespace}, {collection} and {module}")
+	fs.StringVar(&f.prefix, "prefix", "", "Prefix for file, variable and tag names, such as a role name")
+	fs.StringVar(&f.collisions, "collisions", "", "Handling of modules sharing a name: error or qualify")
+	fs.StringVar(&f.layout, "layout", "", "Task file layout: flat, fqcn or collection")
+	fs.BoolVar(&f.subMains, "sub-mains", false, "Write a main.yml per collection directory")
+	fs.StringSliceVar(&f.keywords, "keywords", nil, "Task keywords items may set under _task, such as become,delegate_to")
+	fs.StringVar(&f.order, "order", "", "Option order: alphabetical, documented, required-first or grouped")
+}
+
+// options merges the flags over the config's tasks section. Collection
+// versions for comment headers are looked up on a best-effort basis.
+func (f *taskFlags) options(cfg *atcgConfig.Config, executor atcgModules.CommandExecutor) (atcgTasks.Options, error) {
+	opts := atcgTasks.Options{
+		Comments:          cfg.Tasks.Comments,
+		Guards:            cfg.Tasks.Guards,
+		NoLog:             cfg.Tasks.NoLog,
+		SensitivePatterns: cfg.Tasks.Sensitive,
+		LoopVar:           cfg.Tasks.LoopVar,
+		Label:             cfg.Tasks.Label,
+		Naming:            cfg.Tasks.Naming,
+		Prefix:            cfg.Tasks.Prefix,
+		Collisions:        cfg.Tasks.Collisions,
+		SubMains:          cfg.Tasks.SubMains,
+		Keywords:          cfg.Tasks.Keywords,
+	}
+	if f.fs.Changed("comments") {
+		opts.Comments = f.comments
+	}
+	if f.fs.Changed("guards") {
+		opts.Guards = f.guards
+	}
+	if f.fs.Changed("no-log") {
+		opts.NoLog = f.noLog
+	}
+	if f.fs.Changed("loop-var") {
+		opts.LoopVar = f.loopVar
+	}
+	if f.fs.Changed("label") {
+		opts.Label = f.label
+	}
+	if f.fs.Changed("naming") {
+		opts.Naming = f.naming
+	}
+	if f.fs.Changed("prefix") {
+		opts.Prefix = f.prefix
+	}
+	if f.fs.Changed("collisions") {
+		opts.Collisions = f.collisions
+	}
+	if f.fs.Changed("sub-mains") {
+		opts.SubMains = f.subMains
+	}
+	if f.fs.Changed("keywords") {
+		opts.Keywords = f.keywords
+	}
+	if err := atcgTasks.ValidateKeywords(opts.Keywords); err != nil {
+		return opts, err
+	}
+	if err := atcgTasks.ValidateLoopVar(opts.LoopVar); err != nil {
+		return opts, err
+	}
+	if err := atcgTasks.ValidateNaming(opts.Naming, opts.Prefix, opts.Collisions); err != nil {
+		return opts, err
+	}
+
+	loop := cfg.Tasks.Loop
+	if f.fs.Changed("loop") {
+		loop = f.loop
+	}
+	var err error
+	if opts.Loop, err = atcgTasks.ParseLoop(loop); err != nil {
+		return opts, err
+	}
+
+	layout := cfg.Tasks.Layout
+	if f.fs.Changed("layout") {
+		layout = f.layout
+	}
+	if opts.Layout, err = atcgTasks.ParseLayout(layout); err != nil {
+		return opts, err
+	}
+
+	order := cfg.Tasks.Order
+	if f.fs.Changed("order") {
+		order = f.order
+	}
+	if opts.Order, err = atcgTasks.ParseOrder(order); err != nil {
+		return opts, err
+	}
+	for _, group := range cfg.Tasks.Groups {
+		opts.Groups = append(opts.Groups, atcgTasks.Group{Name: group.Name, Options: group.Options})
+	}
+
+	if opts.Comments {
+		inventory, err := atcgModules.LoadInventory(executor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: collection versions unavailable: %v\n", err)
+		}
+		opts.Inventory = inventory
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	atcgDiff "atcg/internal/atcg/diff"
+	atcgLint "atcg/internal/atcg/lint"
+	atcgMigrate "atcg/internal/atcg/migrate"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runFQCNMigrate implements the fqcn-migrate subcommand.
+func runFQCNMigrate(args []string) error {
+	var format, reportFile string
+	var write bool
+	var collections []string
+	var common commonFlags
+
+	fs := newFlagSet("fqcn-migrate", "atcg fqcn-migrate [flags] [path...]", "Rewrite short and redirected module names in playbooks, roles and task files\nto their canonical FQCNs, resolved as Ansible resolves them. Only the names\nchange; comments and formatting are kept. Without --write a diff is shown.\nCalls that need a human decision are listed in a report on stderr.\nExits non-zero when the diff is not empty or names need a decision.")
+	fs.BoolVarP(&write, "write", "w", false, "Rewrite the files in place instead of showing a diff")
+	fs.StringVar(&reportFile, "report", "", "Write the report to this file instead of stderr")
+	fs.StringVarP(&format, "format", "f", "text", fmt.Sprintf("Report format (%s)", strings.Join(atcgMigrate.Formats, ", ")))
+	fs.StringSliceVar(&collections, "collections", nil, "Collections searched for short names in task files outside plays and roles")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := atcgMigrate.Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	if !fs.Changed("collections") {
+		collections = cfg.Collections
+	}
+	for _, collection := range collections {
+		if err := atcgModules.ValidateCollection(collection); err != nil {
+			return err
+		}
+	}
+	files, err := scanFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	lookup := newFQCNLookup(executor)
+	var report atcgMigrate.Report
+	changed := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		searched := roleCollections(file)
+		if searched == nil {
+			searched = collections
+		}
+		rewritten, found, err := atcgMigrate.FQCN(file, content, searched, lookup.lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		report.Add(found)
+		if len(found.Changes) == 0 {
+			continue
+		}
+		changed++
+		if !write {
+			slashed := filepath.ToSlash(file)
+			fmt.Print(atcgDiff.Unified("a/"+slashed, "b/"+slashed, string(content), string(rewritten)))
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, rewritten, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+
+	var out io.Writer = os.Stderr
+	if reportFile != "" {
+		f, err := os.Create(reportFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := atcgMigrate.WriteReport(out, format, report); err != nil {
+		return err
+	}
+
+	if write {
+		fmt.Printf("Rewrote %d module names in %d files\n", len(report.Changes), changed)
+	}
+	switch {
+	case len(report.Ambiguities) > 0:
+		return fmt.Errorf("%d module calls need a decision", len(report.Ambiguities))
+	case !write && changed > 0:
+		return fmt.Errorf("%d module names to rewrite in %d files", len(report.Changes), changed)
+	}
+	return nil
+}
+
+// fqcnLookup resolves the module calls of fqcn-migrate, remembering the
+// result for each name and search list.
+type fqcnLookup struct {
+	executor atcgModules.CommandExecutor
+	routing  func() atcgModules.Routing
+	results  map[string]fqcnResult
+	warned   map[string]bool
+	// byBasename indexes the installed modules by basename, loaded the first
+	// time a name cannot be resolved.
+	byBasename map[string][]string
+}
+
+type fqcnResult struct {
+	name       string
+	candidates []string
+	err        error
+}
+
+func newFQCNLookup(executor atcgModules.CommandExecutor) *fqcnLookup {
+	return &fqcnLookup{executor: executor, routing: atcgModules.LazyRouting(executor), results: make(map[string]fqcnResult), warned: make(map[string]bool)}
+}
+
+// lookup resolves a call as Ansible would. A name that does not resolve is
+// reported with the installed modules sharing its basename.
+func (l *fqcnLookup) lookup(call atcgLint.Call) (string, []string, error) {
+	key := call.Module + "|" + strings.Join(call.Collections, ",")
+	if result, ok := l.results[key]; ok {
+		return result.name, result.candidates, result.err
+	}
+
+	var result fqcnResult
+	resolver := &atcgModules.Resolver{Exec: l.executor, LoadRouting: l.routing, Collections: call.Collections}
+	resolved, err := resolver.Resolve(call.Module)
+	var removed *atcgModules.RemovedError
+	switch {
+	case err == nil:
+		result.name = resolved.Name
+		if !l.warned[call.Module] {
+			l.warned[call.Module] = true
+			for _, warning := range resolved.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s\n", call.File, call.Line, warning)
+			}
+		}
+	case errors.As(err, &removed):
+		result.err = err
+	default:
+		result.candidates = l.candidates(call.Module)
+		switch len(result.candidates) {
+		case 0:
+			result.err = fmt.Errorf("not found: %v", err)
+		case 1:
+			result.err = fmt.Errorf("not found through the collections searched, only %s is installed", result.candidates[0])
+		default:
+			result.err = fmt.Errorf("not found through the collections searched, several installed modules share the name")
+		}
+	}
+	l.results[key] = result
+	return result.name, result.candidates, result.err
+}
+
+// candidates returns the installed modules with the basename of module.
+func (l *fqcnLookup) candidates(module string) []string {
+	if l.byBasename == nil {
+		l.byBasename = make(map[string][]string)
+		modules, err := atcgModules.ListModules(l.executor, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: listing installed modules: %v\n", err)
+		}
+		for _, m := range modules {
+			base := m.Name[strings.LastIndex(m.Name, ".")+1:]
+			l.byBasename[base] = append(l.byBasename[base], m.Name)
+		}
+	}
+	return l.byBasename[module[strings.LastIndex(module, ".")+1:]]
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+	atcgUtils "atcg/internal/atcg/utils"
+)
+
+// runGenerate implements the generate subcommand, which is also what atcg
+// runs when given bare flags.
+func runGenerate(args []string) error {
+	var output, examples string
+	var minimal bool
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("generate", "atcg generate [flags]", "Generate a task file per module and a main.yml that includes them.")
+	mods.register(fs)
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory for generated tasks")
+	fs.StringVar(&examples, "examples", "", "Also write a sample vars file per module to this directory, e.g. examples")
+	fs.BoolVar(&minimal, "minimal-examples", false, "Only write required options in the sample vars files")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if err := atcgUtils.ValidateInputs(modules); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+
+	if err := Run(modules, outputDir(fs, output, cfg), executor, opts); err != nil {
+		return err
+	}
+	if examples == "" {
+		return nil
+	}
+	return writeExamples(modules, examples, executor, opts, minimal)
+}
+
+// writeExamples writes a sample vars file named after each module to dir.
+// Modules whose documentation cannot be read are reported and skipped, as
+// Run skips them.
+func writeExamples(modules []string, dir string, executor atcgModules.CommandExecutor, opts atcgTasks.Options, minimal bool) error {
+	opts, err := opts.WithNames(modules)
+	if err != nil {
+		return err
+	}
+	for _, module := range modules {
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err != nil {
+			fmt.Printf("error fetching documentation for module %s: %v\n", module, err)
+			continue
+		}
+		example, err := atcgTasks.RenderExample(module, doc, opts, minimal)
+		if err != nil {
+			return err
+		}
+		file, err := atcgTasks.OutputPath(dir, opts.Name(module)+".yml")
+		if err != nil {
+			return err
+		}
+		if err := writeVarsFile(file, []byte("---\n"+example)); err != nil {
+			return err
+		}
+		fmt.Printf("Generated example vars for %s: %s\n", module, file)
+	}
+	return nil
+}
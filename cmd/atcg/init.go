@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	atcgConfig "atcg/internal/atcg/config"
+)
+
+// runInit implements the init subcommand.
+func runInit(args []string) error {
+	var modules []string
+	var output, path string
+	var force bool
+
+	fs := newFlagSet("init", "atcg init [flags]", "Write a starter atcg.yml.")
+	fs.StringSliceVarP(&modules, "module", "m", nil, "Ansible module to list in the config (can be used multiple times)")
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory to put in the config")
+	fs.StringVarP(&path, "config", "c", atcgConfig.DefaultFile, "Configuration file to write")
+	fs.BoolVarP(&force, "force", "f", false, "Overwrite an existing file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+
+	if err := os.WriteFile(path, []byte(atcgConfig.Starter(modules, output)), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runLint implements the lint subcommand.
+func runLint(args []string) error {
+	var output, format string
+	var skip []string
+	var noDocs, strict bool
+	var common commonFlags
+
+	fs := newFlagSet("lint", "atcg lint [flags] [path...]", "Check task files and playbooks against ansible-lint style rules, using module\ndocumentation for the option checks. Directories are searched for .yml and\n.yaml files; without paths the output directory is linted.\nExits non-zero when there are errors, or any findings with --strict.")
+	fs.StringVarP(&output, "output", "o", "tasks", "Directory to lint when no paths are given")
+	fs.StringVarP(&format, "format", "f", "text", fmt.Sprintf("Report format (%s)", strings.Join(atcgLint.Formats, ", ")))
+	fs.StringSliceVar(&skip, "skip", nil, "Rule to skip, e.g. fqcn[action] or args (can be used multiple times)")
+	fs.BoolVar(&noDocs, "no-docs", false, "Skip the rules that need module documentation")
+	fs.BoolVar(&strict, "strict", false, "Exit non-zero on warnings too")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	format, err := atcgLint.Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{outputDir(fs, output, cfg)}
+	}
+	files, err := findFiles(paths, ".yml", ".yaml")
+	if err != nil {
+		return err
+	}
+
+	linter := &atcgLint.Linter{SensitivePatterns: cfg.Tasks.Sensitive, Skip: skip}
+	if !noDocs {
+		linter.Docs = func(module string) (*atcgModules.ModuleDoc, error) {
+			return atcgModules.ParseModuleDoc(executor, module)
+		}
+		linter.Routing = atcgModules.LazyRouting(executor)
+	}
+
+	var findings []atcgLint.Finding
+	for _, file := range files {
+		found, err := linter.LintFile(file)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, found...)
+	}
+	if err := atcgLint.Write(os.Stdout, format, findings, version); err != nil {
+		return err
+	}
+
+	return lintResult(findings, len(files), strict)
+}
+
+// lintResult fails when findings include errors, or any findings when
+// strict.
+func lintResult(findings []atcgLint.Finding, files int, strict bool) error {
+	failing := 0
+	for _, finding := range findings {
+		if strict || finding.Severity == atcgLint.Error {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d findings in %d files", failing, files)
+	}
+	return nil
+}
+
+// findFiles expands directories to the files below them that have one of
+// extensions, sorted.
+func findFiles(paths []string, extensions ...string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && hasExtension(file, extensions) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func hasExtension(file string, extensions []string) bool {
+	for _, extension := range extensions {
+		if filepath.Ext(file) == extension {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runList implements the list subcommand.
+func runList(args []string) error {
+	var format string
+	var common commonFlags
+
+	fs := newFlagSet("list", "atcg list [collection] [flags]", "List the modules ansible-doc knows about, optionally only those of one collection.")
+	fs.StringVar(&format, "format", "text", "Output format: text or json")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("expected at most one collection, got %d arguments", fs.NArg())
+	}
+
+	_, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+
+	modules, err := atcgModules.ListModules(executor, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, module := range modules {
+			fmt.Fprintf(tw, "%s\t%s\n", module.Name, module.ShortDescription)
+		}
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(modules)
+	default:
+		return fmt.Errorf("unknown format %q, expected text or json", format)
+	}
+}
@@ -1,39 +1,91 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	atcgModules "atcg/internal/atcg/modules"
 	atcgTasks "atcg/internal/atcg/tasks"
 	atcgUtils "atcg/internal/atcg/utils"
+)
 
-	"github.com/spf13/pflag"
+// Build information, injected by the Makefile via -ldflags.
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
 )
 
+// command is an atcg subcommand.
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+// commands lists the subcommands in the order they are shown in help.
+var commands = []command{
+	{"generate", "Generate task files and main.yml (default)", runGenerate},
+	{"list", "List available modules", runList},
+	{"show", "Show a module's documentation", runShow},
+	{"diff", "Show what generate would change in the output directory", runDiff},
+	{"validate", "Check the configuration and module documentation", runValidate},
+	{"lint", "Check task files against ansible-lint style rules", runLint},
+	{"validate-vars", "Check vars files against the generated tasks", runValidateVars},
+	{"check-playbook", "Check module options in playbooks, roles and task files", runCheckPlaybook},
+	{"scan", "List the modules an existing project uses", runScan},
+	{"fqcn-migrate", "Rewrite short module names in existing files to FQCNs", runFQCNMigrate},
+	{"extract", "Turn repeated tasks into generated tasks and loop items", runExtract},
+	{"migrate-vars", "Rename vars keys after module options change", runMigrateVars},
+	{"vars", "Write vars files: import loop items, or examples", runVars},
+	{"init", "Write a starter atcg.yml", runInit},
+	{"doctor", "Check the environment atcg depends on", runDoctor},
+	{"version", "Print version information", runVersion},
+}
+
 // Run encapsulates the core logic of the main function for testing.
-func Run(modules []string, outputDir string, executor atcgModules.CommandExecutor) error {
+func Run(modules []string, outputDir string, executor atcgModules.CommandExecutor, opts atcgTasks.Options) error {
 	// Input validation
-	atcgUtils.ValidateInputs(modules)
+	if err := atcgUtils.ValidateInputs(modules); err != nil {
+		return err
+	}
+
+	// Resolve file and variable names before writing anything
+	opts, err := opts.WithNames(modules)
+	if err != nil {
+		return err
+	}
 
 	// Ensure output directory exists
 	atcgUtils.EnsureOutputDirectory(outputDir)
 
 	// Process modules
 	var moduleDetails []atcgTasks.Module
+	var invalid []string
 
 	for _, module := range modules {
-		result, err := atcgTasks.ProcessModule(module, outputDir, executor)
+		result, err := atcgTasks.ProcessModule(module, outputDir, executor, opts)
 		if err != nil {
 			fmt.Println(err)
+			var yamlErr *atcgTasks.InvalidYAMLError
+			if errors.As(err, &yamlErr) {
+				invalid = append(invalid, module)
+			}
 			continue
 		}
 		moduleDetails = append(moduleDetails, *result)
 	}
 
+	// Invalid output is a bug to report, not a module to leave out quietly
+	if len(invalid) > 0 {
+		return fmt.Errorf("generated invalid YAML for %s; main.yml was not written", strings.Join(invalid, ", "))
+	}
+
 	// Generate main.yml
 	if len(moduleDetails) > 0 {
-		if err := atcgTasks.GenerateMain(moduleDetails, outputDir); err != nil {
+		if err := atcgTasks.GenerateMain(moduleDetails, outputDir, opts); err != nil {
 			return fmt.Errorf("error generating main.yml: %w", err)
 		}
 		fmt.Printf("Generated main.yml in %s\n", outputDir)
@@ -45,16 +97,62 @@ func Run(modules []string, outputDir string, executor atcgModules.CommandExecuto
 }
 
 func main() {
-	var modules []string
-	var outputDir string
-	pflag.StringSliceVarP(&modules, "module", "m", nil, "Ansible module name (can be used multiple times)")
-	pflag.StringVarP(&outputDir, "output", "o", "tasks", "Output directory for generated tasks")
-	pflag.Parse()
-
-	// Execute the core logic
-	executor := &atcgModules.RealExecutor{}
-	if err := Run(modules, outputDir, executor); err != nil {
+	if err := dispatch(os.Args[1:]); err != nil && !errors.Is(err, errHelp) {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// dispatch runs the subcommand named by the first argument. Bare flags, as
+// accepted before subcommands existed, run generate.
+func dispatch(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 {
+			switch args[0] {
+			case "--version", "-v":
+				return runVersion(args[1:])
+			case "--help", "-h":
+				printUsage()
+				return nil
+			}
+		}
+		return runGenerate(args)
+	}
+
+	if args[0] == "help" {
+		if len(args) > 1 {
+			if cmd := findCommand(args[1]); cmd != nil {
+				return cmd.run([]string{"--help"})
+			}
+		}
+		printUsage()
+		return nil
+	}
+
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		printUsage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd.run(args[1:])
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	var b strings.Builder
+	b.WriteString("Usage: atcg <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+	b.WriteString("\nRun 'atcg <command> --help' for the flags of a command.\n")
+	b.WriteString("Running atcg with flags only, e.g. 'atcg -m ansible.builtin.ping', is the same as 'atcg generate'.\n")
+	fmt.Fprint(os.Stderr, b.String())
+}
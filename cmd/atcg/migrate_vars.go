@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	atcgDiff "atcg/internal/atcg/diff"
+	atcgLint "atcg/internal/atcg/lint"
+	atcgMigrate "atcg/internal/atcg/migrate"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runMigrateVars implements the migrate-vars subcommand.
+func runMigrateVars(args []string) error {
+	var format, reportFile string
+	var locked []string
+	var write bool
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("migrate-vars", "atcg migrate-vars [flags] <path>...", "Rename the option keys of loop items in vars files after a collection upgrade:\naliases become the option they stand for, and deprecated or removed options\nthe option that replaces them. With --locked, the documentation saved before\nthe upgrade is compared with the current one. Only the keys change; comments\nand formatting are kept. Without --write a diff is shown. Keys that need a\nhuman decision are listed in a report on stderr. Exits non-zero when the diff\nis not empty or keys need a decision.")
+	mods.register(fs)
+	fs.StringArrayVar(&locked, "locked", nil, "ansible-doc -j output saved before the upgrade (can be used multiple times)")
+	fs.BoolVarP(&write, "write", "w", false, "Rewrite the files in place instead of showing a diff")
+	fs.StringVar(&reportFile, "report", "", "Write the report to this file instead of stderr")
+	fs.StringVarP(&format, "format", "f", "text", fmt.Sprintf("Report format (%s)", strings.Join(atcgMigrate.Formats, ", ")))
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no vars files specified")
+	}
+	format, err := atcgMigrate.Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules specified, use -m or the config file")
+	}
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+
+	migrator := &atcgMigrate.VarsMigrator{Locked: make(map[string]*atcgModules.ModuleDoc)}
+	for _, file := range locked {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		docs, err := atcgModules.ParseDocs(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for name, doc := range docs {
+			migrator.Locked[name] = doc
+		}
+	}
+	for _, module := range modules {
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err != nil {
+			return fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+		}
+		migrator.Modules = append(migrator.Modules, atcgLint.VarsModule{Module: module, Doc: doc, Inputs: opts.Inputs(module, doc)})
+	}
+
+	files, err := findFiles(fs.Args(), ".yml", ".yaml")
+	if err != nil {
+		return err
+	}
+	var report atcgMigrate.Report
+	changed := 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rewritten, found, err := migrator.Migrate(file, content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		report.Add(found)
+		if len(found.Changes) == 0 {
+			continue
+		}
+		changed++
+		if !write {
+			slashed := filepath.ToSlash(file)
+			fmt.Print(atcgDiff.Unified("a/"+slashed, "b/"+slashed, string(content), string(rewritten)))
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, rewritten, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+
+	var out io.Writer = os.Stderr
+	if reportFile != "" {
+		f, err := os.Create(reportFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := atcgMigrate.WriteReport(out, format, report); err != nil {
+		return err
+	}
+
+	if write {
+		fmt.Printf("Renamed %d keys in %d files\n", len(report.Changes), changed)
+	}
+	switch {
+	case len(report.Ambiguities) > 0:
+		return fmt.Errorf("%d keys need a decision", len(report.Ambiguities))
+	case !write && changed > 0:
+		return fmt.Errorf("%d keys to rename in %d files", len(report.Changes), changed)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	atcgConfig "atcg/internal/atcg/config"
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// scanSkipDirs are directories that hold data or third-party code rather
+// than tasks.
+var scanSkipDirs = map[string]bool{
+	".git": true, "ansible_collections": true, "defaults": true, "files": true,
+	"group_vars": true, "host_vars": true, "meta": true, "templates": true,
+	"vars": true,
+}
+
+// scanSkipFiles are YAML files that hold configuration or dependencies rather
+// than tasks, some of them top-level lists that would read as task lists.
+var scanSkipFiles = map[string]bool{
+	"requirements.yml": true, "requirements.yaml": true, "galaxy.yml": true,
+	"galaxy.yaml": true, "molecule.yml": true, "execution-environment.yml": true,
+	"ansible-navigator.yml": true, ".ansible-lint.yml": true, ".yamllint.yml": true,
+	".pre-commit-config.yaml": true, ".pre-commit-hooks.yaml": true,
+	".gitlab-ci.yml": true, "docker-compose.yml": true, "mkdocs.yml": true,
+}
+
+// runScan implements the scan subcommand.
+func runScan(args []string) error {
+	var output string
+	var writeConfig, generate, force bool
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("scan", "atcg scan [flags] [project-dir...]", "List the modules an existing Ansible project calls, by fully qualified name.\nPlaybooks, roles, task files and handlers are parsed; short names are resolved\nthrough the collections keyword and ansible-doc. The list can be written to a\nstarter atcg.yml or generated right away.")
+	fs.StringVarP(&output, "output", "o", "tasks", "Output directory for --generate and --init")
+	fs.BoolVar(&writeConfig, "init", false, "Write the modules to a starter config file (see --config)")
+	fs.BoolVar(&generate, "generate", false, "Generate task files for the modules")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing config file with --init")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if writeConfig && !force {
+		if _, err := os.Stat(common.configPath); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", common.configPath)
+		}
+	}
+
+	// With --init, the config file is the one about to be written.
+	cfg, err := common.config()
+	if writeConfig {
+		cfg, err = atcgConfig.LoadOptional(common.configPath)
+	}
+	if err != nil {
+		return err
+	}
+	executor, err := common.build(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := scanFiles(paths)
+	if err != nil {
+		return err
+	}
+	modules, err := scanModules(files, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no module calls found in %v", paths)
+	}
+
+	if !writeConfig && !generate {
+		for _, module := range modules {
+			fmt.Println(module)
+		}
+		return nil
+	}
+	dir := outputDir(fs, output, cfg)
+	var opts atcgTasks.Options
+	if generate {
+		if opts, err = task.options(cfg, executor); err != nil {
+			return err
+		}
+		if opts, err = opts.WithNames(modules); err != nil {
+			return err
+		}
+		if err := atcgTasks.CheckOutputs(dir, opts.Outputs(modules), files); err != nil {
+			return err
+		}
+	}
+	if writeConfig {
+		if err := os.WriteFile(common.configPath, []byte(atcgConfig.Starter(modules, dir)), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", common.configPath, err)
+		}
+		fmt.Printf("Wrote %s with %d modules\n", common.configPath, len(modules))
+	}
+	if generate {
+		return Run(modules, dir, executor, opts)
+	}
+	return nil
+}
+
+// scanModules returns the sorted, distinct FQCNs of the modules called in
+// files. Calls that cannot be resolved are reported on stderr.
+func scanModules(files []string, executor atcgModules.CommandExecutor) ([]string, error) {
+	routing := atcgModules.LazyRouting(executor)
+
+	found := make(map[string]bool)
+	reported := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, call := range atcgLint.Calls(file, content, roleCollections(file)) {
+			resolver := &atcgModules.Resolver{Exec: executor, LoadRouting: routing, Collections: call.Collections}
+			result, err := resolver.Resolve(call.Module)
+			if err != nil {
+				if !reported[call.Module] {
+					reported[call.Module] = true
+					fmt.Fprintf(os.Stderr, "Warning: %s:%d: cannot resolve %s: %v\n", call.File, call.Line, call.Module, err)
+				}
+				continue
+			}
+			if !reported[call.Module] {
+				reported[call.Module] = true
+				for _, warning := range result.Warnings {
+					fmt.Fprintf(os.Stderr, "Warning: %s:%d: %s\n", call.File, call.Line, warning)
+				}
+			}
+			found[result.Name] = true
+		}
+	}
+
+	modules := make([]string, 0, len(found))
+	for module := range found {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// scanFiles returns the YAML files below paths, leaving out scanSkipDirs and
+// scanSkipFiles. A file named explicitly is always returned.
+func scanFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if file != path && scanSkipDirs[entry.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if file != path && scanSkipFiles[entry.Name()] {
+				return nil
+			}
+			if hasExtension(file, []string{".yml", ".yaml"}) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// roleCollections returns the collections keyword of the role a tasks or
+// handlers file belongs to, from the role's meta/main.yml.
+func roleCollections(file string) []string {
+	dir := filepath.Dir(file)
+	if name := filepath.Base(dir); name != "tasks" && name != "handlers" {
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(dir), "meta", "main.yml"))
+	if err != nil {
+		return nil
+	}
+	var meta struct {
+		Collections []string `yaml:"collections"`
+	}
+	if err := yaml.Unmarshal(content, &meta); err != nil {
+		return nil
+	}
+	return meta.Collections
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	atcgDocs "atcg/internal/atcg/docs"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runShow implements the show subcommand.
+func runShow(args []string) error {
+	var format, color string
+	var common commonFlags
+
+	fs := newFlagSet("show", "atcg show <module> [flags]", "Show the parsed documentation of a module: its options, examples and return values.")
+	fs.StringVar(&format, "format", "text", "Output format: text, markdown or json")
+	fs.StringVar(&color, "color", "auto", "Style text output: auto, always or never")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one module")
+	}
+
+	useColor, err := colorEnabled(color)
+	if err != nil {
+		return err
+	}
+
+	_, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+
+	module := fs.Arg(0)
+	doc, err := atcgModules.ParseModuleDoc(executor, module)
+	if err != nil {
+		return fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+	}
+
+	switch format {
+	case "text":
+		return atcgDocs.WriteText(os.Stdout, module, doc, useColor)
+	case "markdown":
+		return atcgDocs.WriteMarkdown(os.Stdout, module, doc)
+	case "json":
+		return atcgDocs.WriteJSON(os.Stdout, doc)
+	default:
+		return fmt.Errorf("unknown format %q, expected text, markdown or json", format)
+	}
+}
+
+// colorEnabled resolves a --color value. auto styles output only when
+// stdout is a terminal and NO_COLOR is not set.
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("unknown color mode %q, expected auto, always or never", mode)
+	}
+}
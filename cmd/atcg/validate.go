@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// runValidate implements the validate subcommand.
+func runValidate(args []string) error {
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("validate", "atcg validate [flags]", "Check that the configuration loads and that every module's documentation\ncan be fetched and turned into a task.")
+	mods.register(fs)
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules specified, use -m or the config file")
+	}
+
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, module := range modules {
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err == nil {
+			_, err = atcgTasks.GenerateTask(module, doc, opts)
+		}
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", module, err)
+			continue
+		}
+		fmt.Printf("ok   %s\n", module)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d modules failed validation", failed, len(modules))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// runValidateVars implements the validate-vars subcommand.
+func runValidateVars(args []string) error {
+	var skip []string
+	var mods moduleFlags
+	var format string
+	var strict bool
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("validate-vars", "atcg validate-vars [flags] <path>...", "Check hand-written vars files, such as group_vars and host_vars, against the\nvariables the generated tasks read. Directories are searched for .yml, .yaml\nand .json files. Exits non-zero when there are errors, or any findings with\n--strict.")
+	mods.register(fs)
+	fs.StringVarP(&format, "format", "f", "text", fmt.Sprintf("Report format (%s)", strings.Join(atcgLint.Formats, ", ")))
+	fs.StringSliceVar(&skip, "skip", nil, "Rule to skip, e.g. vars[deprecated] (can be used multiple times)")
+	fs.BoolVar(&strict, "strict", false, "Exit non-zero on warnings too")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no vars files specified")
+	}
+	format, err := atcgLint.Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules specified, use -m or the config file")
+	}
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+
+	var varsModules []atcgLint.VarsModule
+	for _, module := range modules {
+		module = strings.TrimSpace(module)
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err != nil {
+			return fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+		}
+		varsModules = append(varsModules, atcgLint.VarsModule{Module: module, Doc: doc, Inputs: opts.Inputs(module, doc)})
+	}
+
+	files, err := findFiles(fs.Args(), ".yml", ".yaml", ".json")
+	if err != nil {
+		return err
+	}
+	linter := &atcgLint.Linter{Skip: skip}
+	var findings []atcgLint.Finding
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, linter.LintVars(file, content, varsModules)...)
+	}
+	if err := atcgLint.Write(os.Stdout, format, findings, version); err != nil {
+		return err
+	}
+	return lintResult(findings, len(files), strict)
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	atcgMigrate "atcg/internal/atcg/migrate"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// varsCommands lists the subcommands of vars.
+var varsCommands = []command{
+	{"import", "Turn a CSV or JSON export into loop items", runVarsImport},
+	{"example", "Write a sample loop item per module", runVarsExample},
+}
+
+// runVars implements the vars subcommand, which groups the commands that
+// write vars files.
+func runVars(args []string) error {
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" || args[0] == "help" {
+		var b strings.Builder
+		b.WriteString("Usage: atcg vars <command> [flags]\n\nCommands:\n")
+		for _, cmd := range varsCommands {
+			fmt.Fprintf(&b, "  %-14s %s\n", cmd.name, cmd.description)
+		}
+		b.WriteString("\nRun 'atcg vars <command> --help' for the flags of a command.\n")
+		fmt.Fprint(os.Stderr, b.String())
+		if len(args) == 0 {
+			return fmt.Errorf("no vars command specified")
+		}
+		return nil
+	}
+	for _, cmd := range varsCommands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	return fmt.Errorf("unknown vars command %q", args[0])
+}
+
+// runVarsImport implements the vars import subcommand.
+func runVarsImport(args []string) error {
+	var columns []string
+	var format, separator, delimiter, output string
+	var force bool
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("vars import", "atcg vars import [flags] <file>", "Turn a table, such as a spreadsheet exported as CSV or a JSON list of\nrecords, into the loop items of a module's generated main.yml. Columns are\nmatched to options by header, ignoring case, spaces and dashes, or mapped with\n--column. Cells are converted to the option type: booleans, numbers, lists\nsplit on --separator or written as JSON, and dicts written as JSON. Values are\nchecked against the documented choices and required options. Use - to read\nstandard input.")
+	mods.register(fs)
+	fs.StringArrayVar(&columns, "column", nil, "Map a column to an option as HEADER=OPTION, or skip it with HEADER= (can be used multiple times)")
+	fs.StringVar(&format, "format", "", "Input format, csv or json (default: from the file extension)")
+	fs.StringVar(&delimiter, "delimiter", "", "CSV field delimiter, e.g. ';' or tab (default: detected from the header)")
+	fs.StringVar(&separator, "separator", ",", "Separator of the values in list cells")
+	fs.StringVarP(&output, "output", "o", "", "Vars file to write (default: standard output)")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing vars file")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one file to import")
+	}
+	file := fs.Arg(0)
+
+	mapping := make(map[string]string, len(columns))
+	for _, column := range columns {
+		header, option, ok := strings.Cut(column, "=")
+		if !ok {
+			return fmt.Errorf("invalid --column value %q, expected HEADER=OPTION", column)
+		}
+		mapping[strings.TrimSpace(header)] = strings.TrimSpace(option)
+	}
+	var comma rune
+	switch delimiter {
+	case "":
+	case "tab", `\t`:
+		comma = '\t'
+	default:
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return fmt.Errorf("invalid --delimiter %q, expected a single character", delimiter)
+		}
+		comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(file), ".json") {
+			format = "json"
+		}
+	}
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("invalid --format %q, expected csv or json", format)
+	}
+	if output != "" && !force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+		}
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) != 1 {
+		return fmt.Errorf("vars import needs exactly one module, use -m")
+	}
+	module := modules[0]
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+	doc, err := atcgModules.ParseModuleDoc(executor, module)
+	if err != nil {
+		return fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+	}
+
+	var content []byte
+	if file == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	var table *atcgMigrate.Table
+	if format == "json" {
+		table, err = atcgMigrate.ReadJSON(file, content)
+	} else {
+		table, err = atcgMigrate.ReadCSV(file, content, comma)
+	}
+	if err != nil {
+		return err
+	}
+
+	inputs := opts.Inputs(module, doc)
+	importer := &atcgMigrate.Importer{Module: module, Doc: doc, Inputs: inputs, Columns: mapping, Separator: separator}
+	items, problems, err := importer.Import(table)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		headers := false
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, problem)
+			headers = headers || problem.Header
+		}
+		if headers {
+			fmt.Fprintln(os.Stderr, "Map columns to options with --column HEADER=OPTION, or skip them with --column HEADER=")
+		}
+		return fmt.Errorf("%s cannot be imported: %d problems", file, len(problems))
+	}
+	vars, err := atcgMigrate.EncodeVars([]atcgMigrate.Variable{{
+		Name:    inputs.Variable,
+		Value:   items,
+		Comment: fmt.Sprintf("Loop items for %s, imported by atcg from %s.", module, filepath.Base(file)),
+	}})
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(vars)
+		return err
+	}
+	if err := writeVarsFile(output, vars); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d items for %s to %s\n", len(table.Rows), module, output)
+	return nil
+}
+
+// runVarsExample implements the vars example subcommand.
+func runVarsExample(args []string) error {
+	var output string
+	var minimal, commented, force bool
+	var mods moduleFlags
+	var common commonFlags
+	var task taskFlags
+
+	fs := newFlagSet("vars example", "atcg vars example [flags]", "Write a sample of the variables the generated tasks read: a loop item per\nmodule setting every option to its default, its first choice or a placeholder\nfor its type, commented as required or optional. Suboptions are written as\nnested items. With --commented, every line is commented out, for pasting into\na role's defaults/main.yml.")
+	mods.register(fs)
+	fs.BoolVar(&minimal, "minimal", false, "Only write required options")
+	fs.BoolVar(&commented, "commented", false, "Comment out every line, as documentation in defaults/main.yml")
+	fs.StringVarP(&output, "output", "o", "", "Vars file to write (default: standard output)")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing vars file")
+	common.register(fs)
+	task.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if output != "" && !force {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+		}
+	}
+
+	cfg, executor, err := common.load()
+	if err != nil {
+		return err
+	}
+	modules, err := mods.resolve(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if len(modules) == 0 {
+		return fmt.Errorf("no modules specified, use -m or the config file")
+	}
+	opts, err := task.options(cfg, executor)
+	if err != nil {
+		return err
+	}
+	if opts, err = opts.WithNames(modules); err != nil {
+		return err
+	}
+
+	examples := make([]string, 0, len(modules))
+	for _, module := range modules {
+		doc, err := atcgModules.ParseModuleDoc(executor, module)
+		if err != nil {
+			return fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+		}
+		example, err := atcgTasks.RenderExample(module, doc, opts, minimal)
+		if err != nil {
+			return err
+		}
+		examples = append(examples, example)
+	}
+	vars := "---\n" + strings.Join(examples, "\n")
+	if commented {
+		var b strings.Builder
+		for _, line := range strings.SplitAfter(strings.Join(examples, "\n"), "\n") {
+			if line == "\n" || strings.HasPrefix(line, "#") {
+				b.WriteString(line)
+			} else if line != "" {
+				b.WriteString("# " + line)
+			}
+		}
+		vars = b.String()
+	}
+
+	if output == "" {
+		_, err := fmt.Print(vars)
+		return err
+	}
+	if err := writeVarsFile(output, []byte(vars)); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote examples for %d modules to %s\n", len(modules), output)
+	return nil
+}
+
+// writeVarsFile writes a vars file, creating its directory.
+func writeVarsFile(name string, content []byte) error {
+	if dir := filepath.Dir(name); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(name, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"fmt"
+)
+
+// runVersion implements the version subcommand.
+func runVersion(args []string) error {
+	fs := newFlagSet("version", "atcg version", "Print the atcg version, commit and build date.")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("atcg %s (commit %s, built %s)\n", version, commit, buildDate)
+	return nil
+}
@@ -2,4 +2,7 @@ module atcg
 
 go 1.23.0
 
-require github.com/spf13/pflag v1.0.5 // indirect
+require (
+	github.com/spf13/pflag v1.0.5
+	gopkg.in/yaml.v3 v3.0.1
+)
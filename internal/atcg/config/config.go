@@ -0,0 +1,230 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// DefaultFile is the configuration file atcg looks for in the current directory.
+const DefaultFile = "atcg.yml"
+
+// Config is the content of an atcg.yml file.
+type Config struct {
+	// Output is the directory generated files are written to.
+	Output string `yaml:"output,omitempty"`
+	// Modules lists the modules to generate tasks for.
+	Modules []string `yaml:"modules,omitempty"`
+	// Collections is searched, in order, for modules given by short name.
+	Collections []string `yaml:"collections,omitempty"`
+	// Ansible selects the Ansible installation used to read module docs.
+	Ansible Ansible `yaml:"ansible,omitempty"`
+	// Tasks controls how task files are rendered.
+	Tasks Tasks `yaml:"tasks,omitempty"`
+}
+
+// Tasks mirrors the rendering flags of generate and diff.
+type Tasks struct {
+	// Comments adds documentation comments to generated task files.
+	Comments bool `yaml:"comments,omitempty"`
+	// Order is the option order: alphabetical, documented, required-first
+	// or grouped.
+	Order string `yaml:"order,omitempty"`
+	// Groups defines the sections of the grouped order.
+	Groups []Group `yaml:"groups,omitempty"`
+	// Guards validates loop items before the module runs.
+	Guards bool `yaml:"guards,omitempty"`
+	// NoLog sets no_log on tasks with sensitive options.
+	NoLog bool `yaml:"no_log,omitempty"`
+	// Sensitive lists the option name patterns treated as secrets,
+	// replacing the built-in list.
+	Sensitive []string `yaml:"sensitive,omitempty"`
+	// Loop is the loop strategy: list, dict or single.
+	Loop string `yaml:"loop,omitempty"`
+	// LoopVar renames the loop variable; "{module}" stands for the module
+	// basename.
+	LoopVar string `yaml:"loop_var,omitempty"`
+	// Label labels loop items by a key option: auto or an option name.
+	Label string `yaml:"label,omitempty"`
+	// Naming names files, variables and tags: short, qualified or a
+	// template using {namespace}, {collection} and {module}.
+	Naming string `yaml:"naming,omitempty"`
+	// Prefix is prepended to every name with an underscore.
+	Prefix string `yaml:"prefix,omitempty"`
+	// Collisions handles modules sharing a name: error or qualify.
+	Collisions string `yaml:"collisions,omitempty"`
+	// Layout places task files: flat, fqcn or collection.
+	Layout string `yaml:"layout,omitempty"`
+	// SubMains writes a main.yml per collection directory.
+	SubMains bool `yaml:"sub_mains,omitempty"`
+	// Keywords lists the task keywords items may set under _task.
+	Keywords []string `yaml:"keywords,omitempty"`
+}
+
+// Group is a named section of options. Options may be names or glob
+// patterns such as "*password*".
+type Group struct {
+	Name    string   `yaml:"name"`
+	Options []string `yaml:"options"`
+}
+
+// Ansible mirrors the executor flags shared by every subcommand.
+type Ansible struct {
+	AnsibleDoc string            `yaml:"ansible_doc,omitempty"`
+	Virtualenv string            `yaml:"venv,omitempty"`
+	Env        map[string]string `yaml:"env,omitempty"`
+	Workdir    string            `yaml:"workdir,omitempty"`
+	CacheDir   string            `yaml:"cache_dir,omitempty"`
+}
+
+// Default returns the configuration used when no atcg.yml exists.
+func Default() *Config {
+	return &Config{Output: "tasks"}
+}
+
+// Load reads a configuration file on top of the defaults. Unknown keys are
+// rejected so that typos do not go unnoticed.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	cfg := Default()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// LoadOptional loads path if it exists and returns the defaults otherwise.
+func LoadOptional(path string) (*Config, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	return Load(path)
+}
+
+// Validate checks the configuration for values atcg cannot work with.
+func (c *Config) Validate() error {
+	if strings.TrimSpace(c.Output) == "" {
+		return fmt.Errorf("output cannot be empty")
+	}
+	for i, module := range c.Modules {
+		if strings.TrimSpace(module) == "" {
+			return fmt.Errorf("modules[%d] cannot be empty", i)
+		}
+		if err := atcgModules.ValidateName(strings.TrimSpace(module)); err != nil {
+			return fmt.Errorf("modules[%d]: %w", i, err)
+		}
+	}
+	for i, collection := range c.Collections {
+		if err := atcgModules.ValidateCollection(collection); err != nil {
+			return fmt.Errorf("collections[%d]: %w", i, err)
+		}
+	}
+	for i, group := range c.Tasks.Groups {
+		if strings.TrimSpace(group.Name) == "" {
+			return fmt.Errorf("tasks.groups[%d].name cannot be empty", i)
+		}
+		if len(group.Options) == 0 {
+			return fmt.Errorf("tasks.groups[%d] (%s) has no options", i, group.Name)
+		}
+	}
+	for i, pattern := range c.Tasks.Sensitive {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("tasks.sensitive[%d] cannot be empty", i)
+		}
+	}
+	for key := range c.Ansible.Env {
+		if key == "" || strings.Contains(key, "=") {
+			return fmt.Errorf("ansible.env has invalid variable name %q", key)
+		}
+	}
+	return nil
+}
+
+// EnvList returns the configured environment as sorted KEY=VALUE entries.
+func (a Ansible) EnvList() []string {
+	env := make([]string, 0, len(a.Env))
+	for key, value := range a.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Starter returns the content of a commented starter atcg.yml.
+func Starter(modules []string, output string) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("# atcg configuration. Command-line flags override these values.\n\n")
+	b.WriteString("# Directory the task files and main.yml are written to.\n")
+	fmt.Fprintf(&b, "output: %s\n\n", quote(output))
+	b.WriteString("# Modules to generate tasks for, by fully qualified collection name.\n")
+	if len(modules) == 0 {
+		b.WriteString("modules: []\n")
+		b.WriteString("#  - ansible.windows.win_user_right\n")
+	} else {
+		b.WriteString("modules:\n")
+		for _, module := range modules {
+			fmt.Fprintf(&b, "  - %s\n", quote(module))
+		}
+	}
+	b.WriteString("\n# Collections searched for modules given by short name, as in a play.\n")
+	b.WriteString("# collections: [ansible.windows]\n")
+	b.WriteString("\n# How task files are rendered.\n")
+	b.WriteString("# tasks:\n")
+	b.WriteString("#   comments: true\n")
+	b.WriteString("#   guards: true\n")
+	b.WriteString("#   no_log: true\n")
+	b.WriteString("#   sensitive: [\"*password*\", \"*token*\", \"*secret*\"]\n")
+	b.WriteString("#   naming: short\n")
+	b.WriteString("#   prefix: myrole_\n")
+	b.WriteString("#   collisions: qualify\n")
+	b.WriteString("#   layout: fqcn\n")
+	b.WriteString("#   sub_mains: true\n")
+	b.WriteString("#   loop: dict\n")
+	b.WriteString("#   loop_var: \"{module}_item\"\n")
+	b.WriteString("#   label: auto\n")
+	b.WriteString("#   keywords: [become, delegate_to, when]\n")
+	b.WriteString("#   order: grouped\n")
+	b.WriteString("#   groups:\n")
+	b.WriteString("#     - name: identity\n")
+	b.WriteString("#       options: [name, username, \"*password*\"]\n")
+	b.WriteString("#     - name: connection\n")
+	b.WriteString("#       options: [hostname, port, validate_certs]\n")
+	b.WriteString("\n# Ansible installation used to read module documentation.\n")
+	b.WriteString("# ansible:\n")
+	b.WriteString("#   ansible_doc: /opt/ansible/bin/ansible-doc\n")
+	b.WriteString("#   venv: ~/.venvs/ansible\n")
+	b.WriteString("#   env:\n")
+	b.WriteString("#     ANSIBLE_COLLECTIONS_PATH: ./collections\n")
+	b.WriteString("#   workdir: .\n")
+	b.WriteString("#   cache_dir: .atcg-cache\n")
+	return b.String()
+}
+
+// quote renders s as a YAML scalar.
+func quote(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Sprintf("%q", s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Success(t *testing.T) {
+	path := writeConfig(t, `---
+output: roles/windows/tasks
+modules:
+  - ansible.windows.win_user_right
+  - ansible.windows.win_service
+collections: [ansible.windows, community.windows]
+tasks:
+  comments: true
+  guards: true
+  no_log: true
+  sensitive: ["*pin*"]
+  loop: dict
+  loop_var: "{module}_item"
+  label: auto
+  naming: qualified
+  prefix: web_
+  collisions: qualify
+  layout: fqcn
+  sub_mains: true
+  keywords: [become, when]
+  order: grouped
+  groups:
+    - name: identity
+      options: [name, "*password*"]
+ansible:
+  venv: /opt/venvs/ansible-9
+  env:
+    ANSIBLE_COLLECTIONS_PATH: ./collections
+    ANSIBLE_CONFIG: ./ansible.cfg
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if cfg.Output != "roles/windows/tasks" {
+		t.Errorf("unexpected output %q", cfg.Output)
+	}
+	if len(cfg.Modules) != 2 || cfg.Modules[1] != "ansible.windows.win_service" {
+		t.Errorf("unexpected modules %v", cfg.Modules)
+	}
+	if len(cfg.Collections) != 2 || cfg.Collections[1] != "community.windows" {
+		t.Errorf("unexpected collections %v", cfg.Collections)
+	}
+	if !cfg.Tasks.Comments || !cfg.Tasks.Guards || !cfg.Tasks.NoLog || cfg.Tasks.Sensitive[0] != "*pin*" {
+		t.Errorf("unexpected tasks flags %+v", cfg.Tasks)
+	}
+	if cfg.Tasks.Loop != "dict" || cfg.Tasks.LoopVar != "{module}_item" || cfg.Tasks.Label != "auto" {
+		t.Errorf("unexpected loop settings %+v", cfg.Tasks)
+	}
+	if cfg.Tasks.Naming != "qualified" || cfg.Tasks.Prefix != "web_" || cfg.Tasks.Collisions != "qualify" {
+		t.Errorf("unexpected naming settings %+v", cfg.Tasks)
+	}
+	if cfg.Tasks.Layout != "fqcn" || !cfg.Tasks.SubMains || len(cfg.Tasks.Keywords) != 2 {
+		t.Errorf("unexpected layout settings %+v", cfg.Tasks)
+	}
+	if cfg.Tasks.Order != "grouped" || len(cfg.Tasks.Groups) != 1 || cfg.Tasks.Groups[0].Options[1] != "*password*" {
+		t.Errorf("unexpected tasks %+v", cfg.Tasks)
+	}
+	expectedEnv := []string{"ANSIBLE_COLLECTIONS_PATH=./collections", "ANSIBLE_CONFIG=./ansible.cfg"}
+	if !reflect.DeepEqual(cfg.Ansible.EnvList(), expectedEnv) {
+		t.Errorf("unexpected env %v", cfg.Ansible.EnvList())
+	}
+}
+
+func TestLoad_EmptyFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, ""))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.Output != "tasks" {
+		t.Errorf("expected default output, got %q", cfg.Output)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantErrMsg string
+	}{
+		{name: "Unknown key", content: "modulez: [x]\n", wantErrMsg: "field modulez not found"},
+		{name: "Invalid YAML", content: "modules: [\n", wantErrMsg: "parsing config"},
+		{name: "Empty output", content: "output: ''\n", wantErrMsg: "output cannot be empty"},
+		{name: "Unnamed group", content: "tasks:\n  groups: [{options: [a]}]\n", wantErrMsg: "tasks.groups[0].name cannot be empty"},
+		{name: "Empty group", content: "tasks:\n  groups: [{name: auth}]\n", wantErrMsg: "tasks.groups[0] (auth) has no options"},
+		{name: "Empty sensitive pattern", content: "tasks:\n  sensitive: ['']\n", wantErrMsg: "tasks.sensitive[0] cannot be empty"},
+		{name: "Empty module", content: "modules: ['']\n", wantErrMsg: "modules[0] cannot be empty"},
+		{name: "Flag as module", content: "modules: [ping, '--help']\n", wantErrMsg: `modules[1]: invalid module name "--help"`},
+		{name: "Path as module", content: "modules: [../../etc/passwd]\n", wantErrMsg: "modules[0]: invalid module name"},
+		{name: "Invalid collection", content: "collections: [ansible]\n", wantErrMsg: `collections[0]: invalid collection name "ansible"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErrMsg) {
+				t.Errorf("unexpected error: got %v, want to contain %q", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestLoadOptional_Missing(t *testing.T) {
+	cfg, err := LoadOptional(filepath.Join(t.TempDir(), DefaultFile))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestStarter_RoundTrips(t *testing.T) {
+	for _, modules := range [][]string{nil, {"ansible.windows.win_user_right", "community.general.ufw"}} {
+		path := writeConfig(t, Starter(modules, "my tasks"))
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatalf("starter config does not load: %v", err)
+		}
+		if cfg.Output != "my tasks" {
+			t.Errorf("unexpected output %q", cfg.Output)
+		}
+		if len(cfg.Modules) != len(modules) {
+			t.Errorf("unexpected modules %v, want %v", cfg.Modules, modules)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	if out := Unified("a", "b", "same\n", "same\n"); out != "" {
+		t.Errorf("expected empty diff, got %q", out)
+	}
+}
+
+func TestUnified_Change(t *testing.T) {
+	oldText := "---\n- name: Configure debug\n  ansible.builtin.debug:\n    msg: old\n  tags: [debug]\n"
+	newText := "---\n- name: Configure debug\n  ansible.builtin.debug:\n    msg: new\n    verbosity: 1\n  tags: [debug]\n"
+
+	expected := `--- a/debug.yml
++++ b/debug.yml
+@@ -1,5 +1,6 @@
+ ---
+ - name: Configure debug
+   ansible.builtin.debug:
+-    msg: old
++    msg: new
++    verbosity: 1
+   tags: [debug]
+`
+	if out := Unified("a/debug.yml", "b/debug.yml", oldText, newText); out != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestUnified_NewFile(t *testing.T) {
+	expected := "--- /dev/null\n+++ b/ping.yml\n@@ -0,0 +1,2 @@\n+---\n+- name: Configure ping\n"
+	if out := Unified("/dev/null", "b/ping.yml", "", "---\n- name: Configure ping\n"); out != expected {
+		t.Errorf("unexpected diff:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 20; i++ {
+		line := strings.Repeat("x", i+1)
+		oldLines = append(oldLines, line)
+		if i == 1 || i == 18 {
+			line += "!"
+		}
+		newLines = append(newLines, line)
+	}
+
+	out := Unified("a", "b", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+	if strings.Count(out, "@@ -") != 2 {
+		t.Errorf("expected two hunks, got:\n%s", out)
+	}
+	if !strings.Contains(out, "@@ -1,5 +1,5 @@") || !strings.Contains(out, "@@ -16,5 +16,5 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", out)
+	}
+}
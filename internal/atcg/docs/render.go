@@ -0,0 +1,284 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	atcgMarkup "atcg/internal/atcg/markup"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// WriteText renders a module's documentation as a terminal page. With
+// color set, headings and markup are styled with ANSI escapes.
+func WriteText(w io.Writer, module string, doc *atcgModules.ModuleDoc, color bool) error {
+	var b strings.Builder
+	heading := func(s string) {
+		if color {
+			s = "\x1b[1m" + s + "\x1b[0m"
+		}
+		b.WriteString(s + "\n")
+	}
+	describe := func(indent string, description atcgModules.Description) {
+		for _, paragraph := range atcgMarkup.ParseAll(description.Paragraphs()) {
+			b.WriteString(indent + atcgMarkup.Terminal(paragraph, color) + "\n")
+		}
+	}
+
+	title := module
+	if doc.ShortDescription != "" {
+		title += " - " + atcgMarkup.Terminal(atcgMarkup.Parse(doc.ShortDescription), false)
+	}
+	heading(title)
+	if doc.Collection != "" || doc.VersionAdded != "" {
+		var meta []string
+		if doc.Collection != "" {
+			meta = append(meta, "collection: "+doc.Collection)
+		}
+		if doc.VersionAdded != "" {
+			meta = append(meta, "added in: "+string(doc.VersionAdded))
+		}
+		b.WriteString(strings.Join(meta, ", ") + "\n")
+	}
+
+	if len(doc.Description.Paragraphs()) > 0 {
+		b.WriteString("\n")
+		heading("DESCRIPTION")
+		describe("  ", doc.Description)
+	}
+
+	if len(doc.Options) > 0 {
+		b.WriteString("\n")
+		heading("OPTIONS")
+		var table strings.Builder
+		tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tTYPE\tREQUIRED\tDEFAULT\tCHOICES\tALIASES")
+		walkOptions(doc.Options, "", "", func(path, prefix string, option atcgModules.ModuleOption) {
+			fmt.Fprintf(tw, "  %s%s\t%s\t%s\t%s\t%s\t%s\n", prefix, lastSegment(path), typeName(option.Type, option.Elements),
+				yesNo(option.Required), formatValue(option.Default), formatList(option.Choices), strings.Join(option.Aliases, ", "))
+		})
+		tw.Flush()
+		b.WriteString(trimLines(table.String()))
+
+		b.WriteString("\n")
+		heading("OPTION DETAILS")
+		walkOptions(doc.Options, "", "", func(path, _ string, option atcgModules.ModuleOption) {
+			b.WriteString("  " + path + "\n")
+			describe("      ", option.Description)
+			if option.VersionAdded != "" {
+				b.WriteString("      added in: " + string(option.VersionAdded) + "\n")
+			}
+		})
+	}
+
+	if strings.TrimSpace(doc.Examples) != "" {
+		b.WriteString("\n")
+		heading("EXAMPLES")
+		for _, line := range strings.Split(strings.Trim(doc.Examples, "\n"), "\n") {
+			b.WriteString(strings.TrimRight("  "+line, " ") + "\n")
+		}
+	}
+
+	if len(doc.Return) > 0 {
+		b.WriteString("\n")
+		heading("RETURN VALUES")
+		var table strings.Builder
+		tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tTYPE\tRETURNED")
+		walkReturn(doc.Return, "", "", func(path, prefix string, value atcgModules.ReturnValue) {
+			fmt.Fprintf(tw, "  %s%s\t%s\t%s\n", prefix, lastSegment(path), typeName(value.Type, value.Elements), value.Returned)
+		})
+		tw.Flush()
+		b.WriteString(trimLines(table.String()))
+
+		b.WriteString("\n")
+		walkReturn(doc.Return, "", "", func(path, _ string, value atcgModules.ReturnValue) {
+			b.WriteString("  " + path + "\n")
+			describe("      ", value.Description)
+			if value.Sample != nil {
+				b.WriteString("      sample: " + formatValue(value.Sample) + "\n")
+			}
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown renders a module's documentation as a Markdown page.
+func WriteMarkdown(w io.Writer, module string, doc *atcgModules.ModuleDoc) error {
+	var b strings.Builder
+	describe := func(description atcgModules.Description) string {
+		var rendered []string
+		for _, paragraph := range atcgMarkup.ParseAll(description.Paragraphs()) {
+			rendered = append(rendered, atcgMarkup.Markdown(paragraph))
+		}
+		return strings.Join(rendered, "<br>")
+	}
+
+	b.WriteString("# " + module + "\n")
+	if doc.ShortDescription != "" {
+		b.WriteString("\n" + atcgMarkup.Markdown(atcgMarkup.Parse(doc.ShortDescription)) + "\n")
+	}
+
+	if descriptions := atcgMarkup.ParseAll(doc.Description.Paragraphs()); len(descriptions) > 0 {
+		b.WriteString("\n## Synopsis\n\n")
+		for _, paragraph := range descriptions {
+			b.WriteString("- " + atcgMarkup.Markdown(paragraph) + "\n")
+		}
+	}
+
+	if len(doc.Options) > 0 {
+		b.WriteString("\n## Parameters\n\n")
+		b.WriteString("| Parameter | Type | Required | Default | Choices | Aliases | Description |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+		walkOptions(doc.Options, "", "", func(path, _ string, option atcgModules.ModuleOption) {
+			var choices, aliases []string
+			for _, choice := range option.Choices {
+				choices = append(choices, "`"+formatValue(choice)+"`")
+			}
+			for _, alias := range option.Aliases {
+				aliases = append(aliases, "`"+alias+"`")
+			}
+			defaultValue := ""
+			if option.Default != nil {
+				defaultValue = "`" + formatValue(option.Default) + "`"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s | %s |\n", path, typeName(option.Type, option.Elements), yesNo(option.Required),
+				tableCell(defaultValue), tableCell(strings.Join(choices, ", ")), strings.Join(aliases, ", "), tableCell(describe(option.Description)))
+		})
+	}
+
+	if strings.TrimSpace(doc.Examples) != "" {
+		b.WriteString("\n## Examples\n\n```yaml\n" + strings.Trim(doc.Examples, "\n") + "\n```\n")
+	}
+
+	if len(doc.Return) > 0 {
+		b.WriteString("\n## Return Values\n\n")
+		b.WriteString("| Key | Type | Returned | Description |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		walkReturn(doc.Return, "", "", func(path, _ string, value atcgModules.ReturnValue) {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", path, typeName(value.Type, value.Elements),
+				tableCell(value.Returned), tableCell(describe(value.Description)))
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the parsed documentation as indented JSON.
+func WriteJSON(w io.Writer, doc *atcgModules.ModuleDoc) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// walkOptions visits options depth first in name order. path is the dotted
+// path of the option and prefix the tree drawing that precedes its name.
+func walkOptions(options map[string]atcgModules.ModuleOption, parent, indent string, visit func(path, prefix string, option atcgModules.ModuleOption)) {
+	names := sortedKeys(options)
+	for i, name := range names {
+		option := options[name]
+		prefix, childIndent := treePrefix(parent, indent, i == len(names)-1)
+		visit(joinPath(parent, name), prefix, option)
+		walkOptions(option.Suboptions, joinPath(parent, name), childIndent, visit)
+	}
+}
+
+// walkReturn is walkOptions for return values.
+func walkReturn(values map[string]atcgModules.ReturnValue, parent, indent string, visit func(path, prefix string, value atcgModules.ReturnValue)) {
+	names := sortedKeys(values)
+	for i, name := range names {
+		value := values[name]
+		prefix, childIndent := treePrefix(parent, indent, i == len(names)-1)
+		visit(joinPath(parent, name), prefix, value)
+		walkReturn(value.Contains, joinPath(parent, name), childIndent, visit)
+	}
+}
+
+// treePrefix returns the tree drawing for an entry and the indentation its
+// children continue from. Top-level entries are not drawn.
+func treePrefix(parent, indent string, last bool) (string, string) {
+	if parent == "" {
+		return "", ""
+	}
+	if last {
+		return indent + "└── ", indent + "    "
+	}
+	return indent + "├── ", indent + "│   "
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func lastSegment(path string) string {
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+func typeName(typ, elements string) string {
+	if elements != "" {
+		return typ + "/" + elements
+	}
+	return typ
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// formatValue renders a documented value, leaving unset values blank.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
+
+func formatList(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = formatValue(value)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// trimLines removes the padding tabwriter leaves after the last column.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableCell escapes a value for use in a Markdown table cell.
+func tableCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
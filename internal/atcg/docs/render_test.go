@@ -0,0 +1,128 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func sampleDoc() *atcgModules.ModuleDoc {
+	return &atcgModules.ModuleDoc{
+		Collection:       "ansible.windows",
+		ShortDescription: "Manage Windows User Rights",
+		Description:      atcgModules.Description{"Add, remove or set User Rights for a group or users.", "See O(action) and M(ansible.windows.win_user)."},
+		Options: map[string]atcgModules.ModuleOption{
+			"name":   {Required: true, Type: "str", Description: []string{"The name of the User Right."}},
+			"action": {Default: "set", Type: "str", Choices: []interface{}{"add", "remove", "set"}, Description: []string{"C(add) will add the users."}},
+			"rules": {
+				Type:     "list",
+				Elements: "dict",
+				Aliases:  []string{"rule"},
+				Suboptions: map[string]atcgModules.ModuleOption{
+					"port":  {Type: "int", Default: 80.0},
+					"proto": {Type: "str", VersionAdded: "1.2.0"},
+				},
+			},
+		},
+		Examples: "\n- name: Add rights\n  ansible.windows.win_user_right:\n    name: SeDenyInteractiveLogonRight\n",
+		Return: map[string]atcgModules.ReturnValue{
+			"added": {Type: "list", Returned: "success", Description: []string{"A list of accounts that were added."}, Sample: []interface{}{"NT AUTHORITY\\SYSTEM"}},
+		},
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteText(&out, "ansible.windows.win_user_right", sampleDoc(), false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := "ansible.windows.win_user_right - Manage Windows User Rights\n" +
+		"collection: ansible.windows\n" +
+		"\n" +
+		"DESCRIPTION\n" +
+		"  Add, remove or set User Rights for a group or users.\n" +
+		"  See `action` and [ansible.windows.win_user].\n" +
+		"\n" +
+		"OPTIONS\n" +
+		"  NAME       TYPE       REQUIRED  DEFAULT  CHOICES           ALIASES\n" +
+		"  action     str        no        set      add, remove, set\n" +
+		"  name       str        yes\n" +
+		"  rules      list/dict  no                                   rule\n" +
+		"  ├── port   int        no        80\n" +
+		"  └── proto  str        no\n" +
+		"\n" +
+		"OPTION DETAILS\n" +
+		"  action\n" +
+		"      `add` will add the users.\n" +
+		"  name\n" +
+		"      The name of the User Right.\n" +
+		"  rules\n" +
+		"  rules.port\n" +
+		"  rules.proto\n" +
+		"      added in: 1.2.0\n" +
+		"\n" +
+		"EXAMPLES\n" +
+		"  - name: Add rights\n" +
+		"    ansible.windows.win_user_right:\n" +
+		"      name: SeDenyInteractiveLogonRight\n" +
+		"\n" +
+		"RETURN VALUES\n" +
+		"  NAME   TYPE  RETURNED\n" +
+		"  added  list  success\n" +
+		"\n" +
+		"  added\n" +
+		"      A list of accounts that were added.\n" +
+		"      sample: [\"NT AUTHORITY\\\\SYSTEM\"]\n"
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestWriteText_Color(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteText(&out, "ansible.windows.win_user_right", sampleDoc(), true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "\x1b[1mOPTIONS\x1b[0m") || !strings.Contains(out.String(), "\x1b[36madd\x1b[0m will add") {
+		t.Errorf("expected styled output, got:\n%q", out.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteMarkdown(&out, "ansible.windows.win_user_right", sampleDoc()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, want := range []string{
+		"# ansible.windows.win_user_right\n\nManage Windows User Rights\n",
+		"## Synopsis\n\n- Add, remove or set User Rights for a group or users.\n",
+		"| `action` | str | no | `set` | `add`, `remove`, `set` |  | `add` will add the users. |\n",
+		"| `rules.port` | int | no | `80` |  |  |  |\n",
+		"## Examples\n\n```yaml\n- name: Add rights\n",
+		"| `added` | list | success | A list of accounts that were added. |\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJSON(&out, sampleDoc()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var decoded atcgModules.ModuleDoc
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Options["rules"].Suboptions["proto"].VersionAdded != "1.2.0" {
+		t.Errorf("unexpected round trip: %+v", decoded.Options["rules"])
+	}
+}
@@ -0,0 +1,187 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// SmokeTestModule is the module whose documentation is fetched to prove the pipeline works.
+const SmokeTestModule = "ansible.builtin.ping"
+
+// BuildInfo identifies the atcg binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+}
+
+// Check is the result of one diagnostic.
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// Report collects everything doctor found out about the environment.
+type Report struct {
+	Build       BuildInfo                `json:"atcg"`
+	Ansible     *atcgModules.AnsibleInfo `json:"ansible,omitempty"`
+	Collections []atcgModules.Collection `json:"collections"`
+	Checks      []Check                  `json:"checks"`
+}
+
+// Options selects the directories doctor should inspect.
+type Options struct {
+	OutputDir string
+	CacheDir  string
+}
+
+// Run executes every check against the given executor.
+func Run(exec atcgModules.CommandExecutor, build BuildInfo, opts Options) *Report {
+	report := &Report{Build: build, Collections: []atcgModules.Collection{}}
+
+	info, err := atcgModules.GetAnsibleInfo(exec)
+	if err != nil {
+		report.add("ansible-doc", StatusFail, err.Error())
+	} else {
+		report.Ansible = info
+		executable := info.Executable
+		if executable == "" {
+			executable = "found"
+		}
+		report.add("ansible-doc", StatusOK, executable)
+		report.add("ansible-core", StatusOK, info.CoreVersion)
+		if info.PythonExecutable != "" {
+			report.add("python", StatusOK, fmt.Sprintf("%s (%s)", info.PythonVersion, info.PythonExecutable))
+		} else {
+			report.add("python", StatusOK, info.PythonVersion)
+		}
+		if info.ConfigFile != "" {
+			report.add("config file", StatusOK, info.ConfigFile)
+		} else {
+			report.add("config file", StatusOK, "none")
+		}
+		if len(info.CollectionPaths) > 0 {
+			report.add("collection paths", StatusOK, strings.Join(info.CollectionPaths, string(os.PathListSeparator)))
+		} else {
+			report.add("collection paths", StatusWarn, "none reported")
+		}
+	}
+
+	collections, err := atcgModules.ListCollections(exec)
+	if err != nil {
+		report.add("collections", StatusWarn, err.Error())
+	} else {
+		report.Collections = append(report.Collections, collections...)
+		report.add("collections", StatusOK, fmt.Sprintf("%d installed", len(collections)))
+	}
+
+	doc, err := atcgModules.ParseModuleDoc(exec, SmokeTestModule)
+	if err != nil {
+		report.add("smoke test", StatusFail, err.Error())
+	} else {
+		report.add("smoke test", StatusOK, fmt.Sprintf("%s documented (%d options)", SmokeTestModule, len(doc.Options)))
+	}
+
+	report.addDir("output directory", opts.OutputDir)
+	report.addDir("cache directory", opts.CacheDir)
+
+	return report
+}
+
+// OK reports whether no check failed.
+func (r *Report) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteText writes the report in human-readable form.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "atcg %s (commit %s, built %s)\n\n", r.Build.Version, r.Build.Commit, r.Build.BuildDate)
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "%-6s %s: %s\n", "["+string(check.Status)+"]", check.Name, check.Message)
+		if check.Name == "collections" {
+			for _, collection := range r.Collections {
+				fmt.Fprintf(&b, "         %s %s (%s)\n", collection.Name, collection.Version, collection.Path)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *Report) add(name string, status Status, message string) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Message: message})
+}
+
+// addDir records whether dir can be written to. An empty dir means the
+// feature using it is disabled.
+func (r *Report) addDir(name, dir string) {
+	if dir == "" {
+		r.add(name, StatusOK, "disabled")
+		return
+	}
+	if err := checkWritable(dir); err != nil {
+		r.add(name, StatusFail, err.Error())
+		return
+	}
+	r.add(name, StatusOK, dir+" is writable")
+}
+
+// checkWritable verifies that files can be created in dir. A directory that
+// does not exist yet is judged by its nearest existing parent, since atcg
+// creates it on demand.
+func checkWritable(dir string) error {
+	target := dir
+	for {
+		info, err := os.Stat(target)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", target)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(target)
+		if parent == target {
+			return fmt.Errorf("%s has no existing parent directory", dir)
+		}
+		target = parent
+	}
+
+	probe, err := os.CreateTemp(target, ".atcg-doctor-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", target, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
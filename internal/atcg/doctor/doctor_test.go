@@ -0,0 +1,123 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"atcg/internal/atcg/mocks"
+)
+
+const versionOutput = `ansible-doc [core 2.15.3]
+  config file = None
+  ansible collection location = /root/.ansible/collections
+  executable location = /usr/bin/ansible-doc
+  python version = 3.11.4 (main) [GCC 12.2.0] (/usr/bin/python3)
+`
+
+func healthyExecutor() *mocks.MockExecutor {
+	return &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			switch {
+			case command == "ansible-galaxy":
+				return []byte(`{"/root/.ansible/collections/ansible_collections": {"ansible.windows": {"version": "2.0.0"}}}`), nil
+			case len(args) == 1 && args[0] == "--version":
+				return []byte(versionOutput), nil
+			default:
+				return []byte(`{"ansible.builtin.ping": {"doc": {"options": {"data": {"default": "pong"}}}}}`), nil
+			}
+		},
+	}
+}
+
+func findCheck(t *testing.T, report *Report, name string) Check {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("check %q not found in %+v", name, report.Checks)
+	return Check{}
+}
+
+func TestRun_Healthy(t *testing.T) {
+	build := BuildInfo{Version: "v1.2.3", Commit: "abc123", BuildDate: "2024-01-01T00:00:00Z"}
+	report := Run(healthyExecutor(), build, Options{OutputDir: filepath.Join(t.TempDir(), "tasks")})
+
+	if !report.OK() {
+		t.Fatalf("expected a healthy report, got %+v", report.Checks)
+	}
+	if report.Ansible.CoreVersion != "2.15.3" {
+		t.Errorf("unexpected core version %q", report.Ansible.CoreVersion)
+	}
+	if len(report.Collections) != 1 || report.Collections[0].Name != "ansible.windows" {
+		t.Errorf("unexpected collections %+v", report.Collections)
+	}
+	if check := findCheck(t, report, "smoke test"); !strings.Contains(check.Message, "1 options") {
+		t.Errorf("unexpected smoke test message %q", check.Message)
+	}
+	if check := findCheck(t, report, "cache directory"); check.Message != "disabled" {
+		t.Errorf("unexpected cache check %+v", check)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{"atcg v1.2.3 (commit abc123", "[ok]   ansible-core: 2.15.3", "ansible.windows 2.0.0"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("expected text output to contain %q, got:\n%s", want, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if decoded["atcg"].(map[string]interface{})["commit"] != "abc123" {
+		t.Errorf("unexpected JSON output: %s", buf.String())
+	}
+}
+
+func TestRun_AnsibleMissing(t *testing.T) {
+	executor := &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			return nil, errors.New("executable file not found")
+		},
+	}
+
+	report := Run(executor, BuildInfo{}, Options{})
+	if report.OK() {
+		t.Fatal("expected a failing report")
+	}
+	if check := findCheck(t, report, "ansible-doc"); check.Status != StatusFail {
+		t.Errorf("unexpected ansible-doc check %+v", check)
+	}
+	if check := findCheck(t, report, "collections"); check.Status != StatusWarn {
+		t.Errorf("unexpected collections check %+v", check)
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritable(filepath.Join(dir, "not", "yet", "created")); err != nil {
+		t.Errorf("expected missing directory under a writable parent to pass, got %v", err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(file); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
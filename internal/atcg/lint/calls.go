@@ -0,0 +1,139 @@
+package lint
+
+import (
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// Call is a module call in a playbook or task file.
+type Call struct {
+	Module string
+	// Collections is the collections search list in effect for the call.
+	Collections []string
+	File        string
+	Line        int
+	Column      int
+	// Name is the node the module name is written in: the task key, or the
+	// value of an action or local_action.
+	Name *yaml.Node
+	// Task is the task mapping and Tasks the task list it is an entry of.
+	Task  *yaml.Node
+	Tasks *yaml.Node
+}
+
+// Arguments returns the arguments of a call as a mapping: those of the
+// action followed by those of the args keyword. Free-form arguments, as in
+// copy src=a dest=b, cannot be returned and are reported as not ok.
+func (c Call) Arguments() (*yaml.Node, bool) {
+	key, value := action(c.Task)
+	if key == nil {
+		return nil, false
+	}
+	if key.Value == "action" || key.Value == "local_action" {
+		module, args, freeForm := actionModule(value)
+		if module == "" || freeForm {
+			return nil, false
+		}
+		value = args
+	}
+
+	args := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, node := range []*yaml.Node{value, mappingValue(c.Task, "args")} {
+		switch {
+		case node == nil || isOmitted(node) || (node == value && node.Kind == yaml.ScalarNode && node.Value == ""):
+		case node.Kind == yaml.MappingNode:
+			args.Content = append(args.Content, node.Content...)
+		default:
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+// Calls returns the module calls of a playbook or task file, in file order.
+// collections is the search list of the enclosing role, from its
+// meta/main.yml; a play's collections keyword replaces it. Includes, imports
+// and calls through a templated action are left out.
+func Calls(file string, content []byte, collections []string) []Call {
+	var calls []Call
+	for _, call := range Actions(file, content, collections) {
+		if !includeActions[call.Module] && !roleActions[call.Module] {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Actions is Calls including includes, imports and roles run as tasks.
+func Actions(file string, content []byte, collections []string) []Call {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var calls []Call
+	var walk func(list *yaml.Node, collections []string)
+	visit := func(task, list *yaml.Node, collections []string) {
+		for _, section := range blockSections {
+			walk(mappingValue(task, section), collections)
+		}
+		key, value := action(task)
+		if key == nil {
+			return
+		}
+		module, name := key.Value, key
+		if module == "action" || module == "local_action" {
+			module, _, _ = actionModule(value)
+			name = value
+			if value.Kind == yaml.MappingNode {
+				name = mappingValue(value, "module")
+			}
+		}
+		if module == "" || IsTemplated(module) {
+			return
+		}
+		calls = append(calls, Call{Module: module, Collections: collections, File: file, Line: key.Line, Column: key.Column, Name: name, Task: task, Tasks: list})
+	}
+	walk = func(list *yaml.Node, collections []string) {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, task := range list.Content {
+			if task.Kind == yaml.MappingNode {
+				visit(task, list, collections)
+			}
+		}
+	}
+
+	tasks := root.Content[0]
+	for _, item := range tasks.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if mappingValue(item, "hosts") == nil {
+			visit(item, tasks, collections)
+			continue
+		}
+		playCollections := collections
+		if list := mappingValue(item, "collections"); list != nil {
+			playCollections = stringList(list)
+		}
+		for _, section := range playSections {
+			walk(mappingValue(item, section), playCollections)
+		}
+	}
+	return calls
+}
+
+// Resolve returns the fully qualified name and documentation of a module,
+// resolved as generate resolves it: through the collections search list,
+// plugin routing when Routing is set, and the name ansible-doc reports.
+func (l *Linter) Resolve(module string, collections []string) (string, *atcgModules.ModuleDoc, error) {
+	resolver := &atcgModules.Resolver{Docs: l.doc, LoadRouting: l.Routing, Collections: collections}
+	result, err := resolver.Resolve(module)
+	if err != nil {
+		return module, nil, err
+	}
+	return result.Name, result.Doc, nil
+}
@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestCalls(t *testing.T) {
+	content := []byte(`---
+- hosts: all
+  collections: [ansible.windows]
+  tasks:
+    - win_user_right:
+        name: x
+    - block:
+        - local_action: copy src=a dest=b
+      rescue:
+        - action:
+            module: ansible.builtin.debug
+    - include_tasks: other.yml
+    - "{{ lookup('x') }}": {}
+- hosts: db
+  handlers:
+    - name: Restart
+      service: name=db state=restarted
+`)
+	var got []string
+	for _, call := range Calls("site.yml", content, []string{"community.general"}) {
+		got = append(got, fmt.Sprintf("%d:%s%v", call.Line, call.Module, call.Collections))
+	}
+	want := []string{
+		"5:win_user_right[ansible.windows]",
+		"8:copy[ansible.windows]",
+		"10:ansible.builtin.debug[ansible.windows]",
+		"17:service[community.general]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	var names []string
+	for _, call := range Actions("site.yml", content, nil) {
+		names = append(names, fmt.Sprintf("%d:%d:%s", call.Name.Line, call.Name.Column, call.Module))
+	}
+	wantNames := []string{"5:7:win_user_right", "8:25:copy", "11:21:ansible.builtin.debug", "12:7:include_tasks", "17:7:service"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("got %v, want %v", names, wantNames)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	known := map[string]*atcgModules.ModuleDoc{
+		"copy":                        {Collection: "ansible.builtin"},
+		"ansible.windows.win_copy":    {Collection: "ansible.windows"},
+		"community.general.copy":      {Collection: "community.general"},
+		"ansible.windows.win_service": {},
+		"win_thing":                   {Name: "community.windows.win_thing"},
+	}
+	l := &Linter{Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+		if doc, ok := known[module]; ok {
+			return doc, nil
+		}
+		return nil, errors.New("not found")
+	}}
+
+	tests := []struct {
+		module      string
+		collections []string
+		want        string
+	}{
+		{"copy", nil, "ansible.builtin.copy"},
+		{"copy", []string{"community.general"}, "community.general.copy"},
+		{"win_copy", []string{"community.general", "ansible.windows"}, "ansible.windows.win_copy"},
+		{"ansible.windows.win_service", nil, "ansible.windows.win_service"},
+		{"win_thing", nil, "community.windows.win_thing"},
+	}
+	for _, tt := range tests {
+		got, _, err := l.Resolve(tt.module, tt.collections)
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q, %v) = %q, %v, want %q", tt.module, tt.collections, got, err, tt.want)
+		}
+	}
+	if _, _, err := l.Resolve("nope", []string{"ansible.windows"}); err == nil {
+		t.Error("expected an error for an unknown module")
+	}
+}
@@ -0,0 +1,282 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// Severity is how serious a finding is.
+type Severity string
+
+const (
+	// Error findings break the task or hide secrets.
+	Error Severity = "error"
+	// Warning findings are style or maintenance issues.
+	Warning Severity = "warning"
+)
+
+// Rule describes a lint rule.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// Rules lists every rule, in report order.
+var Rules = []Rule{
+	{"load-failure", Error, "File is not valid YAML"},
+	{"fqcn[action]", Warning, "Use the fully qualified collection name for actions"},
+	{"name[casing]", Warning, "Task names should start with an uppercase letter"},
+	{"no-log-password", Error, "Tasks setting sensitive options should use no_log"},
+	{"deprecated[module]", Warning, "Module is deprecated"},
+	{"args[deprecated]", Warning, "Option is deprecated"},
+	{"args[unknown]", Error, "Option is not documented by the module"},
+	{"args[required]", Error, "Required option is missing"},
+	{"args[choices]", Error, "Value is not one of the documented choices"},
+	{"jinja[spacing]", Warning, "Jinja delimiters should be padded with spaces"},
+	{"docs[unavailable]", Warning, "Module documentation could not be read"},
+	{"vars[item]", Error, "Variable does not have the shape the generated task loops over"},
+	{"vars[unknown]", Error, "Key is not an option of the module"},
+	{"vars[alias]", Error, "Key is an alias, which generated tasks do not read"},
+	{"vars[deprecated]", Warning, "Key is a deprecated option"},
+	{"vars[required]", Error, "Required option is missing from a loop item"},
+	{"vars[type]", Error, "Value does not match the option type"},
+	{"vars[choices]", Error, "Value is not one of the documented choices"},
+}
+
+// rule returns the rule with the given ID.
+func rule(id string) Rule {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	panic("unknown lint rule " + id)
+}
+
+// Finding is a rule violation at a position in a file.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Message  string   `json:"message"`
+}
+
+// DocFunc returns the documentation of a module.
+type DocFunc func(module string) (*atcgModules.ModuleDoc, error)
+
+// Linter checks task files. Rules that need module documentation are
+// skipped when Docs is nil.
+type Linter struct {
+	Docs DocFunc
+	// Routing, when set, loads the module routing that short and redirected
+	// names are resolved through, as atcgModules.Resolver.LoadRouting.
+	Routing func() atcgModules.Routing
+	// SensitivePatterns are passed to tasks.SensitiveOptions.
+	SensitivePatterns []string
+	// Skip lists rule IDs, or prefixes such as "args", not to report.
+	Skip []string
+	// Only, when set, lists the rule IDs or prefixes to report.
+	Only []string
+
+	docs map[string]docResult
+}
+
+type docResult struct {
+	doc *atcgModules.ModuleDoc
+	err error
+}
+
+// LintFile reads and lints a file.
+func (l *Linter) LintFile(path string) ([]Finding, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return l.Lint(path, content), nil
+}
+
+// Lint lints the content of a task file or playbook. Findings are sorted by
+// position.
+func (l *Linter) Lint(file string, content []byte) []Finding {
+	return l.LintRole(file, content, nil)
+}
+
+// LintRole is Lint for a file of a role, whose short module names are looked
+// up in collections, the search list from the role's meta/main.yml. A play's
+// collections keyword replaces it, as in Calls.
+func (l *Linter) LintRole(file string, content []byte, collections []string) []Finding {
+	c := newChecker(l, file, content)
+	c.collections = collections
+	root := c.load(content)
+	if root != nil && root.Kind == yaml.SequenceNode {
+		for _, item := range root.Content {
+			if item.Kind != yaml.MappingNode {
+				continue
+			}
+			if mappingValue(item, "hosts") != nil {
+				c.play(item)
+			} else {
+				c.task(item)
+			}
+		}
+	}
+	return c.sorted()
+}
+
+// skipped reports whether a rule is disabled by Skip.
+func (l *Linter) skipped(id string) bool {
+	return matchRule(id, l.Skip) || (len(l.Only) > 0 && !matchRule(id, l.Only))
+}
+
+// matchRule reports whether id is one of names, or in the family of one.
+func matchRule(id string, names []string) bool {
+	for _, name := range names {
+		if id == name || strings.HasPrefix(id, name+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// doc returns the cached documentation of a module.
+func (l *Linter) doc(module string) (*atcgModules.ModuleDoc, error) {
+	if l.docs == nil {
+		l.docs = make(map[string]docResult)
+	}
+	result, ok := l.docs[module]
+	if !ok {
+		result.doc, result.err = l.Docs(module)
+		l.docs[module] = result
+	}
+	return result.doc, result.err
+}
+
+// checker holds the state of linting one file.
+type checker struct {
+	linter   *Linter
+	file     string
+	lines    []string
+	findings []Finding
+	// reported avoids repeating per-module findings within a file.
+	reported map[string]bool
+	// collections is the collections search list of the current play.
+	collections []string
+}
+
+func newChecker(l *Linter, file string, content []byte) *checker {
+	return &checker{linter: l, file: file, lines: strings.Split(string(content), "\n"), reported: map[string]bool{}}
+}
+
+// load parses content and returns its top-level node, or nil when the file
+// is empty or not valid YAML.
+func (c *checker) load(content []byte) *yaml.Node {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		c.report("load-failure", 1, 1, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return nil
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+	return root.Content[0]
+}
+
+// sorted returns the findings sorted by position.
+func (c *checker) sorted() []Finding {
+	sort.SliceStable(c.findings, func(i, j int) bool {
+		a, b := c.findings[i], c.findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.findings
+}
+
+var noqaPattern = regexp.MustCompile(`#\s*noqa(?::?\s*([\w\[\], -]+))?`)
+
+// report adds a finding unless the rule is skipped or the line carries a
+// "# noqa" comment, bare or naming the rule.
+func (c *checker) report(id string, line, column int, format string, args ...interface{}) {
+	if c.linter.skipped(id) {
+		return
+	}
+	if line > 0 && line <= len(c.lines) {
+		if match := noqaPattern.FindStringSubmatch(c.lines[line-1]); match != nil {
+			if match[1] == "" {
+				return
+			}
+			if matchRule(id, strings.FieldsFunc(match[1], func(r rune) bool { return r == ' ' || r == ',' })) {
+				return
+			}
+		}
+	}
+
+	r := rule(id)
+	c.findings = append(c.findings, Finding{
+		Rule:     id,
+		Severity: r.Severity,
+		File:     c.file,
+		Line:     line,
+		Column:   column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// playSections are the task lists of a play.
+var playSections = []string{"pre_tasks", "tasks", "post_tasks", "handlers"}
+
+func (c *checker) play(play *yaml.Node) {
+	outer := c.collections
+	c.collections = stringList(mappingValue(play, "collections"))
+	defer func() { c.collections = outer }()
+	for _, section := range playSections {
+		if list := mappingValue(play, section); list != nil && list.Kind == yaml.SequenceNode {
+			for _, task := range list.Content {
+				if task.Kind == yaml.MappingNode {
+					c.task(task)
+				}
+			}
+		}
+	}
+}
+
+// stringList returns the scalars of a sequence node, or a lone scalar.
+func stringList(node *yaml.Node) []string {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	var list []string
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			list = append(list, item.Value)
+		}
+	}
+	return list
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+var userDoc = &atcgModules.ModuleDoc{
+	Collection: "ansible.builtin",
+	Options: map[string]atcgModules.ModuleOption{
+		"name":     {Type: "str", Required: true, Aliases: []string{"user"}},
+		"password": {Type: "str"},
+		"state":    {Type: "str", Choices: []interface{}{"absent", "present"}},
+		"append":   {Type: "bool", Choices: []interface{}{false, true}},
+		"groups":   {Type: "list", Elements: "str"},
+		"local":    {Type: "bool", Deprecated: &atcgModules.Deprecation{Why: "unused"}},
+	},
+}
+
+func docs(module string) (*atcgModules.ModuleDoc, error) {
+	switch module {
+	case "user", "ansible.builtin.user":
+		return userDoc, nil
+	case "ansible.builtin.old":
+		return &atcgModules.ModuleDoc{Deprecated: &atcgModules.Deprecation{Alternative: "ansible.builtin.new"}}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// summary formats findings as "line:rule" for comparison.
+func summary(findings []Finding) []string {
+	var got []string
+	for _, f := range findings {
+		got = append(got, fmt.Sprintf("%d:%s", f.Line, f.Rule))
+	}
+	return got
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "clean",
+			content: `---
+- name: Create user
+  ansible.builtin.user:
+    name: "{{ item.name }}"
+    state: present
+    append: yes
+`,
+		},
+		{
+			name: "short name and casing",
+			content: `- name: create user
+  user:
+    name: bob
+`,
+			want: []string{"1:name[casing]", "2:fqcn[action]"},
+		},
+		{
+			name: "unknown deprecated and missing",
+			content: `- name: Create user
+  ansible.builtin.user:
+    nmae: bob
+    local: true
+`,
+			want: []string{"2:args[required]", "3:args[unknown]", "4:args[deprecated]"},
+		},
+		{
+			name: "alias and args keyword",
+			content: `- name: Create user
+  ansible.builtin.user:
+  args:
+    user: bob
+`,
+		},
+		{
+			name: "free-form args skip required",
+			content: `- name: Create user
+  ansible.builtin.user: "{{ settings }}"
+`,
+		},
+		{
+			name: "choices",
+			content: `- name: Create user
+  ansible.builtin.user:
+    name: bob
+    state: gone
+    groups: [wheel]
+- name: Templated
+  ansible.builtin.user:
+    name: bob
+    state: "{{ user_state }}"
+`,
+			want: []string{"4:args[choices]"},
+		},
+		{
+			name: "no_log",
+			content: `- name: Logged
+  ansible.builtin.user:
+    name: bob
+    password: secret
+- name: Hidden
+  ansible.builtin.user:
+    name: bob
+    password: secret
+  no_log: true
+- name: Templated
+  ansible.builtin.user:
+    name: bob
+    password: secret
+  no_log: "{{ hide }}"
+`,
+			want: []string{"4:no-log-password"},
+		},
+		{
+			name: "jinja spacing",
+			content: `- name: Debug
+  ansible.builtin.debug:
+    msg: "{{item}} {{- ok }} {{ ok -}}"
+    var: "{% if x %}{{ y}}{% endif %}"
+`,
+			want: []string{"2:docs[unavailable]", "3:jinja[spacing]", "4:jinja[spacing]"},
+		},
+		{
+			name: "deprecated module",
+			content: `- name: Old
+  ansible.builtin.old:
+`,
+			want: []string{"2:deprecated[module]"},
+		},
+		{
+			name: "playbook with blocks",
+			content: `- hosts: all
+  tasks:
+    - name: Group
+      block:
+        - name: inner
+          ansible.builtin.user:
+            name: bob
+      rescue:
+        - name: Recover
+          user: name=bob
+`,
+			want: []string{"5:name[casing]", "10:fqcn[action]"},
+		},
+		{
+			name: "noqa",
+			content: `- name: create user  # noqa: name[casing]
+  user:  # noqa
+    name: bob
+`,
+		},
+		{
+			name:    "invalid yaml",
+			content: "- name: [unclosed\n",
+			want:    []string{"1:load-failure"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Linter{Docs: docs}
+			got := summary(l.Lint("tasks/user.yml", []byte(tt.content)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLint_Messages(t *testing.T) {
+	l := &Linter{Docs: docs}
+	findings := l.Lint("user.yml", []byte(`- name: Create user
+  user:
+    name: bob
+    pasword: x
+`))
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %v", findings)
+	}
+	if want := "use the FQCN ansible.builtin.user instead of user"; findings[0].Message != want {
+		t.Errorf("got %q, want %q", findings[0].Message, want)
+	}
+	if want := "did you mean password?"; !strings.HasSuffix(findings[1].Message, want) {
+		t.Errorf("got %q, want suffix %q", findings[1].Message, want)
+	}
+	if findings[1].Line != 4 || findings[1].Column != 5 || findings[1].Severity != Error {
+		t.Errorf("unexpected position or severity: %+v", findings[1])
+	}
+}
+
+func TestLint_Skip(t *testing.T) {
+	l := &Linter{Docs: docs, Skip: []string{"args", "name[casing]"}}
+	findings := l.Lint("user.yml", []byte(`- name: create user
+  ansible.builtin.user:
+    nmae: bob
+`))
+	if len(findings) != 0 {
+		t.Errorf("expected skipped rules to be dropped, got %v", findings)
+	}
+}
+
+func TestLint_WithoutDocs(t *testing.T) {
+	l := &Linter{}
+	got := summary(l.Lint("user.yml", []byte(`- name: Create user
+  user:
+    nmae: bob
+`)))
+	if want := []string{"2:fqcn[action]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLint_CachesDocs(t *testing.T) {
+	calls := 0
+	l := &Linter{Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+		calls++
+		return docs(module)
+	}}
+	content := []byte("- name: A\n  ansible.builtin.user:\n    name: a\n- name: B\n  ansible.builtin.user:\n    name: b\n")
+	l.Lint("a.yml", content)
+	l.Lint("b.yml", content)
+	if calls != 1 {
+		t.Errorf("expected docs to be read once, got %d calls", calls)
+	}
+}
+
+func TestLint_Only(t *testing.T) {
+	l := &Linter{Docs: docs, Only: []string{"args"}}
+	got := summary(l.Lint("user.yml", []byte(`- name: create user
+  user:
+    nmae: bob
+`)))
+	if want := []string{"2:args[required]", "3:args[unknown]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLint_ActionsAndCollections(t *testing.T) {
+	l := &Linter{Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+		if module == "ansible.windows.win_user_right" {
+			return &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"name": {Required: true}}}, nil
+		}
+		return docs(module)
+	}}
+	findings := l.Lint("site.yml", []byte(`- hosts: all
+  collections:
+    - ansible.windows
+  tasks:
+    - name: Right
+      win_user_right:
+        nmae: x
+    - name: Local
+      local_action:
+        module: ansible.builtin.user
+        name: bob
+        stat: present
+    - name: Free-form
+      local_action: ansible.builtin.user name=bob
+`))
+	got := summary(findings)
+	want := []string{"6:fqcn[action]", "6:args[required]", "7:args[unknown]", "12:args[unknown]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := "use the FQCN ansible.windows.win_user_right instead of win_user_right"; findings[0].Message != want {
+		t.Errorf("got %q, want %q", findings[0].Message, want)
+	}
+}
+
+func TestLintRole(t *testing.T) {
+	l := &Linter{Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+		if module == "ansible.windows.win_user_right" {
+			return &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"name": {Required: true}}}, nil
+		}
+		return docs(module)
+	}, Only: []string{"args"}}
+	got := summary(l.LintRole("roles/site/tasks/main.yml", []byte(`- name: Right
+  win_user_right:
+    nmae: x
+`), []string{"ansible.windows"}))
+	if want := []string{"2:args[required]", "3:args[unknown]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeActions are the actions that pull in another task file or playbook.
+var includeActions = map[string]bool{
+	"include_tasks": true, "import_tasks": true, "import_playbook": true,
+	"ansible.builtin.include_tasks": true, "ansible.builtin.import_tasks": true,
+	"ansible.builtin.import_playbook": true,
+}
+
+// roleActions are the actions that run a role.
+var roleActions = map[string]bool{
+	"include_role": true, "import_role": true,
+	"ansible.builtin.include_role": true, "ansible.builtin.import_role": true,
+}
+
+// roleDirs are the directories of a role that hold tasks.
+var roleDirs = []string{"tasks", "handlers"}
+
+// References returns the files and role directories that a playbook or task
+// file imports, includes or runs, resolved against the file's directory.
+// Templated names and references that do not exist on disk are left out.
+func References(file string, content []byte) []string {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	r := &references{dir: filepath.Dir(file)}
+	for _, item := range root.Content[0].Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if mappingValue(item, "hosts") != nil {
+			for _, role := range stringList(roleNames(mappingValue(item, "roles"))) {
+				r.role(role)
+			}
+			for _, section := range playSections {
+				r.tasks(mappingValue(item, section))
+			}
+		} else {
+			r.task(item)
+		}
+	}
+	return r.found
+}
+
+type references struct {
+	dir   string
+	found []string
+}
+
+func (r *references) tasks(list *yaml.Node) {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, task := range list.Content {
+		if task.Kind == yaml.MappingNode {
+			r.task(task)
+		}
+	}
+}
+
+func (r *references) task(task *yaml.Node) {
+	for _, section := range blockSections {
+		r.tasks(mappingValue(task, section))
+	}
+
+	key, value := action(task)
+	if key == nil {
+		return
+	}
+	switch {
+	case includeActions[key.Value]:
+		target := value
+		if value.Kind == yaml.MappingNode {
+			target = mappingValue(value, "file")
+		}
+		if target != nil && target.Kind == yaml.ScalarNode {
+			r.file(target.Value)
+		}
+	case roleActions[key.Value]:
+		if name := mappingValue(value, "name"); name != nil {
+			r.role(name.Value)
+		}
+	}
+}
+
+// file adds a referenced file relative to the directory.
+func (r *references) file(name string) {
+	if name == "" || IsTemplated(name) {
+		return
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, name)
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		r.found = append(r.found, path)
+	}
+}
+
+// role adds the task directories of a role found next to a playbook, or
+// next to the role whose tasks reference it.
+func (r *references) role(name string) {
+	if name == "" || IsTemplated(name) || strings.Count(name, ".") >= 2 {
+		// Collection roles are not on disk next to the project.
+		return
+	}
+	for _, base := range []string{filepath.Join(r.dir, "roles"), filepath.Join(r.dir, "..", ".."), filepath.Join(r.dir, "..", "roles")} {
+		roleDir := filepath.Join(base, name)
+		if info, err := os.Stat(roleDir); err != nil || !info.IsDir() {
+			continue
+		}
+		for _, dir := range roleDirs {
+			if info, err := os.Stat(filepath.Join(roleDir, dir)); err == nil && info.IsDir() {
+				r.found = append(r.found, filepath.Join(roleDir, dir))
+			}
+		}
+		return
+	}
+}
+
+// roleNames turns the roles of a play, given as names or as mappings with a
+// role or name key, into a list of names.
+func roleNames(roles *yaml.Node) *yaml.Node {
+	if roles == nil || roles.Kind != yaml.SequenceNode {
+		return nil
+	}
+	names := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, role := range roles.Content {
+		switch role.Kind {
+		case yaml.ScalarNode:
+			names.Content = append(names.Content, role)
+		case yaml.MappingNode:
+			if name := mappingValue(role, "role"); name != nil {
+				names.Content = append(names.Content, name)
+			} else if name := mappingValue(role, "name"); name != nil {
+				names.Content = append(names.Content, name)
+			}
+		}
+	}
+	return names
+}
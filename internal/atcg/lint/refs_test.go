@@ -0,0 +1,58 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"common.yml", "other.yml", "roles/web/tasks/main.yml", "roles/web/handlers/main.yml", "roles/db/tasks/main.yml", "roles/ntp/tasks/main.yml"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("---\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := []byte(`---
+- import_playbook: other.yml
+- hosts: all
+  roles:
+    - web
+    - role: db
+    - community.general.missing
+  tasks:
+    - ansible.builtin.import_tasks: common.yml
+    - name: Templated
+      include_tasks: "{{ file }}.yml"
+    - name: Missing
+      include_tasks:
+        file: missing.yml
+    - block:
+        - include_role:
+            name: ntp
+`)
+	got := References(filepath.Join(dir, "site.yml"), playbook)
+	want := []string{
+		filepath.Join(dir, "other.yml"),
+		filepath.Join(dir, "roles", "web", "tasks"),
+		filepath.Join(dir, "roles", "web", "handlers"),
+		filepath.Join(dir, "roles", "db", "tasks"),
+		filepath.Join(dir, "common.yml"),
+		filepath.Join(dir, "roles", "ntp", "tasks"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// A role's tasks find sibling roles.
+	got = References(filepath.Join(dir, "roles", "web", "tasks", "main.yml"), []byte("- import_role:\n    name: db\n"))
+	if want := []string{filepath.Join(dir, "roles", "web", "tasks", "..", "..", "db", "tasks")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// FormatList lists the report formats of a command.
+type FormatList []string
+
+// Parse validates a report format. An empty format is text.
+func (f FormatList) Parse(format string) (string, error) {
+	if format == "" {
+		return "text", nil
+	}
+	for _, known := range f {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (want one of %v)", format, []string(f))
+}
+
+// Formats lists the report formats.
+var Formats = FormatList{"text", "json", "sarif"}
+
+// Write writes findings in the given format. version is the atcg version
+// reported as the SARIF tool version.
+func Write(w io.Writer, format string, findings []Finding, version string) error {
+	format, err := Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		return WriteJSON(w, findings)
+	case "sarif":
+		return WriteSARIF(w, findings, version)
+	}
+	return WriteText(w, findings)
+}
+
+// WriteText writes one finding per line, in the file:line:column form
+// editors understand.
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s (%s)\n", f.File, f.Line, f.Column, f.Rule, f.Message, f.Severity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON array.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
+
+// SARIF 2.1.0 types, limited to what code-scanning dashboards read.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine   int `json:"startLine"`
+			StartColumn int `json:"startColumn"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log with a single run.
+// File paths are written as relative, slash-separated URIs.
+func WriteSARIF(w io.Writer, findings []Finding, version string) error {
+	driver := sarifDriver{
+		Name:           "atcg",
+		InformationURI: "https://github.com/kbcz1989/atcg",
+		Version:        version,
+	}
+	index := make(map[string]int, len(Rules))
+	for i, r := range Rules {
+		rule := sarifRule{ID: r.ID, ShortDescription: sarifMessage{r.Description}}
+		rule.DefaultConfiguration.Level = string(r.Severity)
+		driver.Rules = append(driver.Rules, rule)
+		index[r.ID] = i
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(f.File)
+		location.PhysicalLocation.Region.StartLine = f.Line
+		location.PhysicalLocation.Region.StartColumn = f.Column
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index[f.Rule],
+			Level:     string(f.Severity),
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,110 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var reportFindings = []Finding{
+	{Rule: "args[unknown]", Severity: Error, File: "tasks/user.yml", Line: 3, Column: 5, Message: "ansible.builtin.user has no option nmae"},
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "text", reportFindings, "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "tasks/user.yml:3:5: args[unknown]: ansible.builtin.user has no option nmae (error)\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "json", nil, "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected an empty array, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "json", reportFindings, "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []Finding
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got) != 1 || got[0] != reportFindings[0] {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "sarif", reportFindings, "1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "atcg" || run.Tool.Driver.Version != "1.0.0" || len(run.Tool.Driver.Rules) != len(Rules) {
+		t.Errorf("unexpected driver: %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(run.Results))
+	}
+	result := run.Results[0]
+	if result.RuleID != "args[unknown]" || run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID || result.Level != "error" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "tasks/user.yml" || location.Region.StartLine != 3 {
+		t.Errorf("unexpected location: %+v", location)
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "xml", nil, "")
+	if err == nil || !strings.Contains(err.Error(), `unknown format "xml"`) {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// taskKeywords are the keys of a task that are not its action. action and
+// local_action are left out so that they are found as the action.
+var taskKeywords = map[string]bool{
+	"always": true, "any_errors_fatal": true, "args": true, "async": true,
+	"become": true, "become_exe": true, "become_flags": true,
+	"become_method": true, "become_user": true, "block": true,
+	"changed_when": true, "check_mode": true, "collections": true,
+	"connection": true, "debugger": true, "delay": true,
+	"delegate_facts": true, "delegate_to": true, "diff": true,
+	"environment": true, "failed_when": true, "ignore_errors": true,
+	"ignore_unreachable": true, "listen": true, "loop": true,
+	"loop_control": true, "module_defaults": true, "name": true,
+	"no_log": true, "notify": true, "poll": true, "port": true,
+	"register": true, "remote_user": true, "rescue": true, "retries": true,
+	"run_once": true, "tags": true, "throttle": true, "timeout": true,
+	"until": true, "vars": true, "when": true,
+}
+
+// blockSections are the task lists of a block.
+var blockSections = []string{"block", "rescue", "always"}
+
+// task lints a task or block.
+func (c *checker) task(task *yaml.Node) {
+	c.name(task)
+	c.jinja(task)
+
+	if mappingValue(task, "block") != nil {
+		for _, section := range blockSections {
+			if list := mappingValue(task, section); list != nil && list.Kind == yaml.SequenceNode {
+				for _, child := range list.Content {
+					if child.Kind == yaml.MappingNode {
+						c.task(child)
+					}
+				}
+			}
+		}
+		return
+	}
+
+	key, value := action(task)
+	if key == nil {
+		return
+	}
+	module := key.Value
+	freeForm := value.Kind == yaml.ScalarNode && value.Value != ""
+	if module == "action" || module == "local_action" {
+		module, value, freeForm = actionModule(value)
+		if module == "" {
+			return
+		}
+	}
+	if extra := mappingValue(task, "args"); extra != nil && extra.Kind == yaml.ScalarNode {
+		// args: "{{ settings }}" may carry any option
+		freeForm = true
+	}
+
+	resolved, doc := c.resolve(module, key)
+	if !strings.Contains(module, ".") {
+		if resolved != module {
+			c.report("fqcn[action]", key.Line, key.Column, "use the FQCN %s instead of %s", resolved, module)
+		} else {
+			c.report("fqcn[action]", key.Line, key.Column, "use the fully qualified collection name instead of %s", module)
+		}
+	}
+	if doc == nil {
+		return
+	}
+	if doc.Deprecated != nil {
+		c.report("deprecated[module]", key.Line, key.Column, "%s is deprecated: %s", module, doc.Deprecated)
+	}
+
+	args := taskArgs(task, value)
+	c.args(task, module, doc, args, freeForm, key)
+}
+
+// actionModule returns the module and arguments of an action or
+// local_action value, in either the "module arg=value" form or the mapping
+// form with a module key.
+func actionModule(value *yaml.Node) (string, *yaml.Node, bool) {
+	if value.Kind == yaml.MappingNode {
+		module := mappingValue(value, "module")
+		if module == nil {
+			return "", nil, false
+		}
+		args := &yaml.Node{Kind: yaml.MappingNode}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			if value.Content[i].Value != "module" {
+				args.Content = append(args.Content, value.Content[i], value.Content[i+1])
+			}
+		}
+		return module.Value, args, false
+	}
+	fields := strings.Fields(value.Value)
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	return fields[0], value, len(fields) > 1
+}
+
+// action returns the key and value of a task's action, or nil when it has
+// none.
+func action(task *yaml.Node) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(task.Content); i += 2 {
+		key := task.Content[i].Value
+		if taskKeywords[key] || strings.HasPrefix(key, "with_") {
+			continue
+		}
+		return task.Content[i], task.Content[i+1]
+	}
+	return nil, nil
+}
+
+// taskArgs merges the action mapping with the args keyword, keyed by
+// argument name.
+func taskArgs(task, value *yaml.Node) map[string][2]*yaml.Node {
+	args := make(map[string][2]*yaml.Node)
+	for _, node := range []*yaml.Node{value, mappingValue(task, "args")} {
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			args[node.Content[i].Value] = [2]*yaml.Node{node.Content[i], node.Content[i+1]}
+		}
+	}
+	return args
+}
+
+// resolve returns the name and documentation of a module, looking short
+// names up in the collections of the play. Documentation that cannot be
+// read is reported once per file and nil is returned, as it is without a
+// Docs function.
+func (c *checker) resolve(module string, key *yaml.Node) (string, *atcgModules.ModuleDoc) {
+	if c.linter.Docs == nil {
+		return module, nil
+	}
+	resolved, doc, err := c.linter.Resolve(module, c.collections)
+	if err != nil {
+		if !c.reported[module] {
+			c.reported[module] = true
+			c.report("docs[unavailable]", key.Line, key.Column, "skipping option checks for %s: %v", module, err)
+		}
+		return module, nil
+	}
+	return resolved, doc
+}
+
+// args checks a task's arguments against the module's options.
+func (c *checker) args(task *yaml.Node, module string, doc *atcgModules.ModuleDoc, args map[string][2]*yaml.Node, freeForm bool, key *yaml.Node) {
+	canonical := make(map[string]string)
+	for name, option := range doc.Options {
+		canonical[name] = name
+		for _, alias := range option.Aliases {
+			canonical[alias] = name
+		}
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sensitive := make(map[string]bool)
+	for _, name := range atcgTasks.SensitiveOptions(doc, c.linter.SensitivePatterns) {
+		sensitive[name] = true
+	}
+	noLog := mappingValue(task, "no_log")
+	logged := noLog == nil || (noLog.Tag == "!!bool" && !isTrue(noLog))
+
+	present := make(map[string]bool)
+	for _, name := range names {
+		k, v := args[name][0], args[name][1]
+		option, ok := canonical[name]
+		if !ok {
+			if suggestion := Suggest(name, optionNames(canonical)); suggestion != "" {
+				c.report("args[unknown]", k.Line, k.Column, "%s has no option %s, did you mean %s?", module, name, suggestion)
+			} else {
+				c.report("args[unknown]", k.Line, k.Column, "%s has no option %s", module, name)
+			}
+			continue
+		}
+		present[option] = true
+		spec := doc.Options[option]
+
+		if spec.Deprecated != nil {
+			c.report("args[deprecated]", k.Line, k.Column, "option %s is deprecated: %s", name, spec.Deprecated)
+		}
+		if invalid := invalidChoice(spec, v); invalid != nil {
+			c.report("args[choices]", invalid.Line, invalid.Column, "%s is not a valid value for %s (want one of %s)", invalid.Value, name, formatChoices(spec.Choices))
+		}
+		if sensitive[option] && logged {
+			c.report("no-log-password", k.Line, k.Column, "%s is sensitive, set no_log: true on the task", name)
+		}
+	}
+
+	if freeForm {
+		return
+	}
+	for _, name := range atcgModules.OptionNames(doc.Options) {
+		if doc.Options[name].Required && !present[name] {
+			c.report("args[required]", key.Line, key.Column, "%s requires option %s", module, name)
+		}
+	}
+}
+
+func optionNames(canonical map[string]string) []string {
+	names := make([]string, 0, len(canonical))
+	for name := range canonical {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// invalidChoice returns the literal scalar, or list element, of value that
+// is not among the option's choices. Templated values are not checked.
+func invalidChoice(spec atcgModules.ModuleOption, value *yaml.Node) *yaml.Node {
+	if len(spec.Choices) == 0 || spec.Type == "bool" {
+		return nil
+	}
+	values := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		values = value.Content
+	}
+	for _, v := range values {
+		if v.Kind != yaml.ScalarNode || v.Tag == "!!null" || IsTemplated(v.Value) {
+			continue
+		}
+		if !matchesChoice(spec.Choices, v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func matchesChoice(choices []interface{}, value *yaml.Node) bool {
+	for _, choice := range choices {
+		switch choice := choice.(type) {
+		case bool:
+			if value.Tag == "!!bool" && isTrue(value) == choice {
+				return true
+			}
+		default:
+			if fmt.Sprint(choice) == value.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatChoices(choices []interface{}) string {
+	parts := make([]string, len(choices))
+	for i, choice := range choices {
+		parts[i] = fmt.Sprint(choice)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// IsTemplated reports whether value holds a Jinja expression or statement.
+func IsTemplated(value string) bool {
+	return strings.Contains(value, "{{") || strings.Contains(value, "{%")
+}
+
+// name checks that a task name starts with an uppercase letter.
+func (c *checker) name(task *yaml.Node) {
+	name := mappingValue(task, "name")
+	if name == nil || name.Kind != yaml.ScalarNode || name.Value == "" || strings.HasPrefix(name.Value, "{") {
+		return
+	}
+	first := []rune(name.Value)[0]
+	if unicode.IsLetter(first) && !unicode.IsUpper(first) {
+		c.report("name[casing]", name.Line, name.Column, "task name %q should start with an uppercase letter", name.Value)
+	}
+}
+
+var jinjaPattern = regexp.MustCompile(`(\{\{|\{%)-?(.*?)-?(\}\}|%\})`)
+
+// jinja checks the spacing inside the Jinja delimiters of a task's scalar
+// values, without descending into nested blocks.
+func (c *checker) jinja(task *yaml.Node) {
+	for i := 0; i+1 < len(task.Content); i += 2 {
+		if isBlockSection(task.Content[i].Value) {
+			continue
+		}
+		c.jinjaNode(task.Content[i+1])
+	}
+}
+
+func (c *checker) jinjaNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		for _, match := range jinjaPattern.FindAllStringSubmatch(node.Value, -1) {
+			inner := match[2]
+			if !strings.HasPrefix(inner, " ") || !strings.HasSuffix(inner, " ") {
+				c.report("jinja[spacing]", node.Line, node.Column, "pad %s with spaces, as in %s %s %s", match[0], match[1], strings.TrimSpace(inner), match[3])
+				return
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			c.jinjaNode(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			c.jinjaNode(child)
+		}
+	}
+}
+
+func isBlockSection(key string) bool {
+	for _, section := range blockSections {
+		if key == section {
+			return true
+		}
+	}
+	return false
+}
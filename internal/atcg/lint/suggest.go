@@ -0,0 +1,38 @@
+package lint
+
+// Suggest returns the candidate closest to name by edit distance, or "" when
+// none is close enough to be a likely typo.
+func Suggest(name string, candidates []string) string {
+	best, bestDistance := "", len(name)/3+1
+	if bestDistance < 2 {
+		bestDistance = 2
+	}
+	for _, candidate := range candidates {
+		if d := distance(name, candidate); d <= bestDistance && (best == "" || d < distance(name, best)) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
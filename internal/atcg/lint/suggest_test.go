@@ -0,0 +1,19 @@
+package lint
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"name", "password", "state", "update_password"}
+	tests := map[string]string{
+		"nmae":     "name",
+		"pasword":  "password",
+		"State":    "state",
+		"pwd":      "",
+		"hostname": "",
+	}
+	for name, want := range tests {
+		if got := Suggest(name, candidates); got != want {
+			t.Errorf("Suggest(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package lint
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// VarsModule is a module whose generated task file reads variables.
+type VarsModule struct {
+	Module string
+	Doc    *atcgModules.ModuleDoc
+	Inputs atcgTasks.Inputs
+}
+
+// FindVar returns the module whose task file reads the top-level variable
+// key, and the option a flat variable holds, as atcgTasks.Inputs.Owns. The
+// longest prefix wins when one variable starts with another.
+func FindVar(modules []VarsModule, key string) (VarsModule, string, bool) {
+	var found VarsModule
+	var option string
+	ok := false
+	for _, m := range modules {
+		if m.Inputs.Loop != atcgTasks.LoopSingle {
+			if m.Inputs.Variable == key {
+				return m, "", true
+			}
+			continue
+		}
+		if o, owns := m.Inputs.Owns(key); owns && (!ok || len(m.Inputs.Variable) > len(found.Inputs.Variable)) {
+			found, option, ok = m, o, true
+		}
+	}
+	return found, option, ok
+}
+
+// LintVars checks a YAML or JSON vars file against the variables the
+// generated tasks of modules read. Variables no module reads are ignored.
+func (l *Linter) LintVars(file string, content []byte, modules []VarsModule) []Finding {
+	c := newChecker(l, file, content)
+	root := c.load(content)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return c.sorted()
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		m, option, ok := FindVar(modules, key.Value)
+		switch {
+		case !ok:
+		case m.Inputs.Loop != atcgTasks.LoopSingle:
+			c.loopVar(m, key, value)
+		default:
+			c.flatVar(m, option, key, value)
+		}
+	}
+	return c.sorted()
+}
+
+// loopVar checks the list or dict main.yml loops over.
+func (c *checker) loopVar(m VarsModule, key, value *yaml.Node) {
+	if isOmitted(value) {
+		return
+	}
+	switch m.Inputs.Loop {
+	case atcgTasks.LoopDict:
+		if value.Kind != yaml.MappingNode {
+			c.report("vars[item]", value.Line, value.Column, "%s must be a mapping of items", key.Value)
+			return
+		}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			c.item(m, value.Content[i+1], key.Value+"."+value.Content[i].Value)
+		}
+	default:
+		if value.Kind != yaml.SequenceNode {
+			c.report("vars[item]", value.Line, value.Column, "%s must be a list of items", key.Value)
+			return
+		}
+		for i, item := range value.Content {
+			c.item(m, item, key.Value+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+// item checks a loop item.
+func (c *checker) item(m VarsModule, node *yaml.Node, path string) {
+	if node.Kind == yaml.ScalarNode && IsTemplated(node.Value) {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		c.report("vars[item]", node.Line, node.Column, "%s must be a mapping of options", path)
+		return
+	}
+	if value := mappingValue(node, atcgTasks.TaskKey); value != nil && len(m.Inputs.Keywords) > 0 {
+		c.keywords(m, value, path)
+	}
+	extra := ""
+	if len(m.Inputs.Keywords) > 0 {
+		extra = atcgTasks.TaskKey
+	}
+	// The key option defaults to the dict key.
+	c.options(path, m.Doc.Options, node, extra, m.Inputs.KeyOption)
+}
+
+// options checks the keys of a mapping against options, recursing into
+// suboptions. The extra key is accepted as it is, and the optional option
+// may be left out even when required.
+func (c *checker) options(path string, options map[string]atcgModules.ModuleOption, node *yaml.Node, extra, optional string) {
+	aliases := make(map[string]string)
+	names := make([]string, 0, len(options))
+	for name, option := range options {
+		names = append(names, name)
+		for _, alias := range option.Aliases {
+			aliases[alias] = name
+		}
+	}
+	sort.Strings(names)
+
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		name := key.Value
+		if name == extra && extra != "" {
+			continue
+		}
+		option, ok := options[name]
+		if !ok {
+			if canonical, ok := aliases[name]; ok {
+				present[canonical] = true
+				c.report("vars[alias]", key.Line, key.Column, "%s.%s is an alias of %s, which is the key generated tasks read", path, name, canonical)
+			} else if suggestion := Suggest(name, names); suggestion != "" {
+				c.report("vars[unknown]", key.Line, key.Column, "%s has no option %s, did you mean %s?", path, name, suggestion)
+			} else {
+				c.report("vars[unknown]", key.Line, key.Column, "%s has no option %s", path, name)
+			}
+			continue
+		}
+		present[name] = true
+		c.value(path+"."+name, option, key, value)
+	}
+
+	for _, name := range names {
+		if options[name].Required && !present[name] && name != optional {
+			c.report("vars[required]", node.Line, node.Column, "%s is missing required option %s", path, name)
+		}
+	}
+}
+
+// value checks the value of an option.
+func (c *checker) value(path string, option atcgModules.ModuleOption, key, value *yaml.Node) {
+	if option.Deprecated != nil {
+		c.report("vars[deprecated]", key.Line, key.Column, "%s is deprecated: %s", path, option.Deprecated)
+	}
+	if isOmitted(value) || (value.Kind == yaml.ScalarNode && IsTemplated(value.Value)) {
+		return
+	}
+	if !matchesType(option.Type, value) {
+		c.report("vars[type]", value.Line, value.Column, "%s must be of type %s, got %s", path, option.Type, describe(value))
+		return
+	}
+	if invalid := invalidChoice(option, value); invalid != nil {
+		c.report("vars[choices]", invalid.Line, invalid.Column, "%s is not a valid value for %s (want one of %s)", invalid.Value, path, formatChoices(option.Choices))
+	}
+
+	if len(option.Suboptions) == 0 {
+		return
+	}
+	switch value.Kind {
+	case yaml.MappingNode:
+		c.options(path, option.Suboptions, value, "", "")
+	case yaml.SequenceNode:
+		for i, element := range value.Content {
+			if element.Kind == yaml.MappingNode {
+				c.options(path+"["+strconv.Itoa(i)+"]", option.Suboptions, element, "", "")
+			}
+		}
+	}
+}
+
+// keywords checks the task keywords of an item.
+func (c *checker) keywords(m VarsModule, node *yaml.Node, path string) {
+	if isOmitted(node) {
+		return
+	}
+	if node.Kind != yaml.MappingNode {
+		c.report("vars[item]", node.Line, node.Column, "%s.%s must be a mapping of task keywords", path, atcgTasks.TaskKey)
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !contains(m.Inputs.Keywords, key.Value) {
+			c.report("vars[unknown]", key.Line, key.Column, "%s.%s.%s is not an allowed task keyword (want any of %s)", path, atcgTasks.TaskKey, key.Value, strings.Join(m.Inputs.Keywords, ", "))
+			continue
+		}
+		if value := node.Content[i+1]; atcgTasks.IsConditional(key.Value) && !isCondition(value) {
+			c.report("vars[type]", value.Line, value.Column, "%s.%s.%s must be a boolean or a \"{{ }}\" expression; %s is always true", path, atcgTasks.TaskKey, key.Value, describe(value))
+		}
+	}
+}
+
+// isCondition reports whether value is a condition an item can hold: a
+// boolean, or a string holding a template that is evaluated when read.
+func isCondition(value *yaml.Node) bool {
+	return value.Kind == yaml.ScalarNode && (value.Tag == "!!bool" || strings.Contains(value.Value, "{{"))
+}
+
+// flatVar checks a variable of a LoopSingle module. Required options are not
+// checked, since flat variables may be spread over several files.
+func (c *checker) flatVar(m VarsModule, suffix string, key, value *yaml.Node) {
+	if suffix == "" {
+		if key.Value == m.Inputs.Variable+atcgTasks.TaskKey {
+			c.keywords(m, value, m.Inputs.Variable)
+		}
+		return
+	}
+	if option, ok := m.Doc.Options[suffix]; ok {
+		c.value(key.Value, option, key, value)
+		return
+	}
+
+	names := make([]string, 0, len(m.Doc.Options))
+	for name, option := range m.Doc.Options {
+		names = append(names, name)
+		if contains(option.Aliases, suffix) {
+			c.report("vars[alias]", key.Line, key.Column, "%s uses the alias %s of %s, which generated tasks do not read; use %s", key.Value, suffix, name, m.Inputs.Flat(name))
+			return
+		}
+	}
+	sort.Strings(names)
+	if suggestion := Suggest(suffix, names); suggestion != "" {
+		c.report("vars[unknown]", key.Line, key.Column, "%s has no option %s, did you mean %s?", m.Module, suffix, m.Inputs.Flat(suggestion))
+	} else {
+		c.report("vars[unknown]", key.Line, key.Column, "%s has no option %s", m.Module, suffix)
+	}
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	floatPattern = regexp.MustCompile(`^[+-]?([0-9]+[.]?[0-9]*|[.][0-9]+)([eE][+-]?[0-9]+)?$`)
+)
+
+// matchesType reports whether a value is accepted for an option type, by the
+// same rules as the guards of generated tasks.
+func matchesType(optionType string, value *yaml.Node) bool {
+	scalar := value.Kind == yaml.ScalarNode
+	switch optionType {
+	case "str":
+		return scalar
+	case "int":
+		return scalar && intPattern.MatchString(value.Value)
+	case "float":
+		return scalar && floatPattern.MatchString(value.Value)
+	case "bool":
+		_, ok := ParseBool(value.Value)
+		return scalar && ok
+	case "list":
+		return value.Kind == yaml.SequenceNode || (scalar && value.Tag == "!!str")
+	case "dict":
+		return value.Kind == yaml.MappingNode || (scalar && value.Tag == "!!str")
+	}
+	return true
+}
+
+// describe names the YAML type of a value.
+func describe(value *yaml.Node) string {
+	switch value.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	return strings.TrimPrefix(value.ShortTag(), "!!") + " " + value.Value
+}
+
+// isOmitted reports whether a value is null, which modules treat as unset.
+func isOmitted(value *yaml.Node) bool {
+	return value.Kind == yaml.ScalarNode && value.ShortTag() == "!!null"
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBool parses the spellings of a boolean Ansible accepts.
+func ParseBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "on", "t", "1":
+		return true, true
+	case "false", "no", "n", "off", "f", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// isTrue reports whether value spells true.
+func isTrue(value *yaml.Node) bool {
+	b, _ := ParseBool(value.Value)
+	return b
+}
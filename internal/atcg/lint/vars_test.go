@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+var rightDoc = &atcgModules.ModuleDoc{
+	Options: map[string]atcgModules.ModuleOption{
+		"name":   {Type: "str", Required: true},
+		"users":  {Type: "list", Elements: "str", Required: true},
+		"action": {Type: "str", Choices: []interface{}{"add", "remove", "set"}},
+		"force":  {Type: "bool", Aliases: []string{"overwrite"}},
+		"port":   {Type: "int"},
+		"legacy": {Type: "str", Deprecated: &atcgModules.Deprecation{Alternative: "name"}},
+		"acl": {Type: "list", Elements: "dict", Suboptions: map[string]atcgModules.ModuleOption{
+			"user":   {Type: "str", Required: true},
+			"rights": {Type: "str", Choices: []interface{}{"read", "full"}},
+		}},
+	},
+}
+
+func varsModule(opts atcgTasks.Options) []VarsModule {
+	module := "ansible.windows.win_user_right"
+	return []VarsModule{{Module: module, Doc: rightDoc, Inputs: opts.Inputs(module, rightDoc)}}
+}
+
+func TestLintVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    atcgTasks.Options
+		content string
+		want    []string
+	}{
+		{
+			name: "valid list",
+			content: `---
+other: [1, 2]
+win_user_right:
+  - name: SeDenyBatchLogonRight
+    users: [Guests]
+    action: "{{ right_action }}"
+    force: yes
+    port: "8080"
+    acl:
+      - user: bob
+        rights: read
+  - "{{ extra_right }}"
+`,
+		},
+		{
+			name: "item errors",
+			content: `win_user_right:
+  - name: SeDenyBatchLogonRight
+    user: [Guests]
+    action: append
+    overwrite: true
+    port: eighty
+    legacy: x
+    acl:
+      - rights: none
+  - just a string
+`,
+			want: []string{
+				"2:vars[required]", "3:vars[unknown]", "4:vars[choices]", "5:vars[alias]",
+				"6:vars[type]", "7:vars[deprecated]", "9:vars[required]", "9:vars[choices]", "10:vars[item]",
+			},
+		},
+		{
+			name:    "not a list",
+			content: "win_user_right:\n  name: x\n",
+			want:    []string{"2:vars[item]"},
+		},
+		{
+			name: "dict with key option and keywords",
+			opts: atcgTasks.Options{Loop: atcgTasks.LoopDict, Keywords: []string{"when"}},
+			content: `win_user_right:
+  SeDenyBatchLogonRight:
+    users: [Guests]
+    _task:
+      when: true
+      become: true
+`,
+			want: []string{"6:vars[unknown]"},
+		},
+		{
+			name: "conditions",
+			opts: atcgTasks.Options{Keywords: []string{"when", "failed_when"}},
+			content: `win_user_right:
+  - name: SeDenyBatchLogonRight
+    users: [Guests]
+    _task:
+      when: "{{ ansible_os_family == 'Windows' }}"
+      failed_when: false
+  - name: SeDenyNetworkLogonRight
+    users: [Guests]
+    _task:
+      when: ansible_os_family == 'Windows'
+      failed_when: [false]
+`,
+			want: []string{"10:vars[type]", "11:vars[type]"},
+		},
+		{
+			name: "flat variables",
+			opts: atcgTasks.Options{Loop: atcgTasks.LoopSingle},
+			content: `win_user_right_enabled: true
+win_user_right_name: SeDenyBatchLogonRight
+win_user_right_usrs: [Guests]
+win_user_right_overwrite: true
+win_user_right_action: append
+`,
+			want: []string{"3:vars[unknown]", "4:vars[alias]", "5:vars[choices]"},
+		},
+		{
+			name:    "json",
+			content: `{"win_user_right": [{"name": "x", "users": "Guests", "force": "maybe"}]}`,
+			want:    []string{"1:vars[type]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Linter{}
+			got := summary(l.LintVars("group_vars/all.yml", []byte(tt.content), varsModule(tt.opts)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintVars_Messages(t *testing.T) {
+	l := &Linter{}
+	findings := l.LintVars("vars.yml", []byte("win_user_right:\n  - name: x\n    usrs: [a]\n"), varsModule(atcgTasks.Options{}))
+	var messages []string
+	for _, f := range findings {
+		messages = append(messages, f.Message)
+	}
+	want := []string{
+		"win_user_right[0] is missing required option users",
+		"win_user_right[0] has no option usrs, did you mean users?",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("got %q, want %q", messages, want)
+	}
+	if findings[1].Line != 3 || findings[1].Column != 5 {
+		t.Errorf("unexpected position %d:%d", findings[1].Line, findings[1].Column)
+	}
+
+	findings = l.LintVars("vars.yml", []byte("win_user_right:\n  - name: x\n    users: [a]\n    port: [1]\n"), varsModule(atcgTasks.Options{}))
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "must be of type int, got list") {
+		t.Errorf("unexpected findings %v", findings)
+	}
+
+	findings = l.LintVars("vars.yml", []byte("win_user_right:\n  - name: x\n    users: [a]\n    _task: {when: is_enabled}\n"), varsModule(atcgTasks.Options{Keywords: []string{"when"}}))
+	if len(findings) != 1 || findings[0].Message != `win_user_right[0]._task.when must be a boolean or a "{{ }}" expression; str is_enabled is always true` {
+		t.Errorf("unexpected findings %v", findings)
+	}
+}
+
+func TestFindVar(t *testing.T) {
+	modules := []VarsModule{
+		{Module: "win_user", Inputs: atcgTasks.Inputs{Variable: "win_user", Loop: atcgTasks.LoopSingle}},
+		{Module: "win_user_right", Inputs: atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopSingle}},
+		{Module: "win_group", Inputs: atcgTasks.Inputs{Variable: "win_group", Loop: atcgTasks.LoopList}},
+	}
+	for key, want := range map[string][2]string{
+		"win_user_name":       {"win_user", "name"},
+		"win_user_right_name": {"win_user_right", "name"},
+		"win_user_enabled":    {"win_user", ""},
+		"win_group":           {"win_group", ""},
+	} {
+		m, option, ok := FindVar(modules, key)
+		if !ok || m.Module != want[0] || option != want[1] {
+			t.Errorf("%s: got %s %q %v, want %v", key, m.Module, option, ok, want)
+		}
+	}
+	if _, _, ok := FindVar(modules, "win_group_name"); ok {
+		t.Errorf("expected no module for a list variable prefix")
+	}
+}
@@ -0,0 +1,222 @@
+package markup
+
+import (
+	"strings"
+)
+
+// Kind identifies the type of a Part.
+type Kind int
+
+const (
+	// Text is literal text.
+	Text Kind = iota
+	// Italic is I(text).
+	Italic
+	// Bold is B(text).
+	Bold
+	// Code is C(text).
+	Code
+	// Module is M(fqcn), a reference to another module.
+	Module
+	// URL is U(url).
+	URL
+	// Link is L(text,url).
+	Link
+	// Ref is R(text,anchor), a reference into the Ansible docs.
+	Ref
+	// HorizontalLine is HORIZONTALLINE.
+	HorizontalLine
+	// Option is O(name) or O(name=value).
+	Option
+	// Value is V(value).
+	Value
+	// ReturnValue is RV(name) or RV(name=value).
+	ReturnValue
+	// EnvVar is E(NAME).
+	EnvVar
+	// Plugin is P(fqcn#type).
+	Plugin
+)
+
+// Part is a node of a parsed paragraph.
+type Part struct {
+	Kind Kind
+	// Text is the displayed text: the literal text, the option or return
+	// value name, the link text, the module or plugin name.
+	Text string
+	// Target is the URL of U() and L(), and the anchor of R().
+	Target string
+	// Value is the value of O(name=value) and RV(name=value).
+	Value string
+	// HasValue is set when O() or RV() carried a value, which may be empty.
+	HasValue bool
+	// Plugin is the plugin FQCN of P(), or of O()/RV() when they refer to
+	// another plugin's option, and PluginType its type.
+	Plugin     string
+	PluginType string
+}
+
+// Paragraph is a parsed paragraph of documentation.
+type Paragraph []Part
+
+// command describes a markup command.
+type command struct {
+	kind     Kind
+	semantic bool
+}
+
+// commands maps command names to their shape. Classic commands take their
+// parameter verbatim up to the first closing parenthesis; semantic commands
+// allow \) and \\ escapes.
+var commands = map[string]command{
+	"I":  {Italic, false},
+	"B":  {Bold, false},
+	"C":  {Code, false},
+	"M":  {Module, false},
+	"U":  {URL, false},
+	"L":  {Link, false},
+	"R":  {Ref, false},
+	"O":  {Option, true},
+	"V":  {Value, true},
+	"RV": {ReturnValue, true},
+	"E":  {EnvVar, true},
+	"P":  {Plugin, true},
+}
+
+const horizontalLine = "HORIZONTALLINE"
+
+// Parse parses a paragraph containing Ansible classic and semantic markup.
+// Malformed commands, such as an unterminated parameter, are kept as text.
+func Parse(s string) Paragraph {
+	var parts Paragraph
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, Part{Kind: Text, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		if atWordStart(s, i) {
+			if strings.HasPrefix(s[i:], horizontalLine) && !isWordByte(s, i+len(horizontalLine)) {
+				flush()
+				parts = append(parts, Part{Kind: HorizontalLine})
+				i += len(horizontalLine)
+				continue
+			}
+			if part, n, ok := parseCommand(s[i:]); ok {
+				flush()
+				parts = append(parts, part)
+				i += n
+				continue
+			}
+		}
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+
+	return parts
+}
+
+// ParseAll parses every paragraph of a description.
+func ParseAll(paragraphs []string) []Paragraph {
+	parsed := make([]Paragraph, len(paragraphs))
+	for i, paragraph := range paragraphs {
+		parsed[i] = Parse(paragraph)
+	}
+	return parsed
+}
+
+// parseCommand parses a command at the start of s and returns the part and
+// the number of bytes consumed.
+func parseCommand(s string) (Part, int, bool) {
+	open := strings.IndexByte(s, '(')
+	if open < 1 || open > 2 {
+		return Part{}, 0, false
+	}
+	cmd, ok := commands[s[:open]]
+	if !ok {
+		return Part{}, 0, false
+	}
+
+	param, n, ok := readParam(s[open+1:], cmd.semantic)
+	if !ok {
+		return Part{}, 0, false
+	}
+	consumed := open + 1 + n
+
+	part := Part{Kind: cmd.kind, Text: param}
+	switch cmd.kind {
+	case Link, Ref:
+		comma := strings.IndexByte(param, ',')
+		if comma < 0 {
+			return Part{}, 0, false
+		}
+		part.Text = strings.TrimSpace(param[:comma])
+		part.Target = strings.TrimSpace(param[comma+1:])
+	case URL:
+		part.Target = param
+	case Option, ReturnValue:
+		name := param
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			part.Value = name[eq+1:]
+			part.HasValue = true
+			name = name[:eq]
+		}
+		if plugin, rest, ok := strings.Cut(name, ":"); ok && strings.Contains(plugin, "#") {
+			part.Plugin, part.PluginType, _ = strings.Cut(plugin, "#")
+			name = rest
+		}
+		part.Text = name
+	case Plugin:
+		part.Plugin, part.PluginType, _ = strings.Cut(param, "#")
+		part.Text = part.Plugin
+	}
+
+	return part, consumed, true
+}
+
+// readParam reads a command parameter up to and including the closing
+// parenthesis.
+func readParam(s string, escapes bool) (string, int, bool) {
+	if !escapes {
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return "", 0, false
+		}
+		return s[:end], end + 1, true
+	}
+
+	var param strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == ')' || s[i+1] == '\\') {
+				i++
+			}
+			param.WriteByte(s[i])
+		case ')':
+			return param.String(), i + 1, true
+		default:
+			param.WriteByte(s[i])
+		}
+	}
+	return "", 0, false
+}
+
+// atWordStart reports whether a command may start at s[i], which requires
+// that it is not preceded by a letter, digit or underscore.
+func atWordStart(s string, i int) bool {
+	return i == 0 || !isWordByte(s, i-1)
+}
+
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
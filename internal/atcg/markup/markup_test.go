@@ -0,0 +1,178 @@
+package markup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Paragraph
+	}{
+		{
+			name:     "Plain text",
+			input:    "The action to take.",
+			expected: Paragraph{{Kind: Text, Text: "The action to take."}},
+		},
+		{
+			name:  "Classic markup",
+			input: "Use I(italic), B(bold) and C(code) with M(ansible.windows.win_user).",
+			expected: Paragraph{
+				{Kind: Text, Text: "Use "},
+				{Kind: Italic, Text: "italic"},
+				{Kind: Text, Text: ", "},
+				{Kind: Bold, Text: "bold"},
+				{Kind: Text, Text: " and "},
+				{Kind: Code, Text: "code"},
+				{Kind: Text, Text: " with "},
+				{Kind: Module, Text: "ansible.windows.win_user"},
+				{Kind: Text, Text: "."},
+			},
+		},
+		{
+			name:  "Links",
+			input: "See U(https://example.com) or L(the docs, https://docs.example.com/a_(b)) and R(this, ref_anchor).",
+			expected: Paragraph{
+				{Kind: Text, Text: "See "},
+				{Kind: URL, Text: "https://example.com", Target: "https://example.com"},
+				{Kind: Text, Text: " or "},
+				{Kind: Link, Text: "the docs", Target: "https://docs.example.com/a_(b"},
+				{Kind: Text, Text: ") and "},
+				{Kind: Ref, Text: "this", Target: "ref_anchor"},
+				{Kind: Text, Text: "."},
+			},
+		},
+		{
+			name:  "Semantic markup",
+			input: `Set O(state=absent) or O(force), see RV(changed) and E(ANSIBLE_CONFIG) with V(a\)b\\c).`,
+			expected: Paragraph{
+				{Kind: Text, Text: "Set "},
+				{Kind: Option, Text: "state", Value: "absent", HasValue: true},
+				{Kind: Text, Text: " or "},
+				{Kind: Option, Text: "force"},
+				{Kind: Text, Text: ", see "},
+				{Kind: ReturnValue, Text: "changed"},
+				{Kind: Text, Text: " and "},
+				{Kind: EnvVar, Text: "ANSIBLE_CONFIG"},
+				{Kind: Text, Text: " with "},
+				{Kind: Value, Text: `a)b\c`},
+				{Kind: Text, Text: "."},
+			},
+		},
+		{
+			name:  "Plugin references",
+			input: "P(ansible.builtin.file#lookup) and O(ansible.builtin.copy#module:dest=/tmp).",
+			expected: Paragraph{
+				{Kind: Plugin, Text: "ansible.builtin.file", Plugin: "ansible.builtin.file", PluginType: "lookup"},
+				{Kind: Text, Text: " and "},
+				{Kind: Option, Text: "dest", Value: "/tmp", HasValue: true, Plugin: "ansible.builtin.copy", PluginType: "module"},
+				{Kind: Text, Text: "."},
+			},
+		},
+		{
+			name:  "Horizontal line",
+			input: "Before HORIZONTALLINE After",
+			expected: Paragraph{
+				{Kind: Text, Text: "Before "},
+				{Kind: HorizontalLine},
+				{Kind: Text, Text: " After"},
+			},
+		},
+		{
+			name:     "Commands inside words and malformed commands stay text",
+			input:    "ABC(x) and I(unterminated",
+			expected: Paragraph{{Kind: Text, Text: "ABC(x) and I(unterminated"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("unexpected parse:\ngot  %+v\nwant %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	p := Parse("Set O(state=absent) to remove C(path), see M(ansible.builtin.file) and L(docs,https://example.com).")
+
+	expected := "Set `state=absent` to remove `path`, see [ansible.builtin.file] and docs <https://example.com>."
+	if got := Terminal(p, false); got != expected {
+		t.Errorf("unexpected plain rendering:\ngot  %q\nwant %q", got, expected)
+	}
+
+	expectedColor := "Set \x1b[33mstate=absent\x1b[0m to remove \x1b[36mpath\x1b[0m, see [\x1b[1mansible.builtin.file\x1b[0m] and docs <\x1b[4mhttps://example.com\x1b[0m>."
+	if got := Terminal(p, true); got != expectedColor {
+		t.Errorf("unexpected color rendering:\ngot  %q\nwant %q", got, expectedColor)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	p := Parse("Use B(care) with O(path) and M(ansible.builtin.file), a_b [x] U(https://example.com).")
+
+	expected := "Use **care** with `path` and [`ansible.builtin.file`](https://docs.ansible.com/ansible/latest/collections/ansible/builtin/file_module.html), a\\_b \\[x\\] <https://example.com>."
+	if got := Markdown(p); got != expected {
+		t.Errorf("unexpected markdown:\ngot  %q\nwant %q", got, expected)
+	}
+}
+
+func TestCodeSpan(t *testing.T) {
+	if got := codeSpan("a`b"); got != "``a`b``" {
+		t.Errorf("unexpected code span %q", got)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	parsed := ParseAll([]string{"First C(x).", "Second."})
+	if len(parsed) != 2 || parsed[0][1].Kind != Code || parsed[1][0].Text != "Second." {
+		t.Errorf("unexpected parse: %+v", parsed)
+	}
+}
+
+func TestPlain(t *testing.T) {
+	p := Parse("I(Only) B(works) with O(state=present), see P(ansible.builtin.file#lookup), U(https://example.com) and R(the guide,guide_anchor).")
+
+	expected := "Only works with `state=present`, see [ansible.builtin.file] (lookup), https://example.com and the guide."
+	if got := Plain(p); got != expected {
+		t.Errorf("unexpected plain text:\ngot  %q\nwant %q", got, expected)
+	}
+}
+
+func TestYAMLComment(t *testing.T) {
+	paragraphs := ParseAll([]string{
+		"The action to take. C(add) will add the users to the right, C(remove) will remove them.",
+		"Before HORIZONTALLINE after.",
+	})
+
+	expected := `    # The action to take. ` + "`add`" + ` will add the users to the
+    # right, ` + "`remove`" + ` will remove them.
+    #
+    # Before
+    # -------------
+    # after.
+`
+	if got := YAMLComment(paragraphs, "    ", 60); got != expected {
+		t.Errorf("unexpected comment:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	p := Parse("Use <b> I(carefully) with C(a<b) and M(ansible.builtin.file) or L(docs,https://example.com/?a=1&b=2).")
+
+	expected := `Use &lt;b&gt; <em>carefully</em> with <code>a&lt;b</code> and <a href="https://docs.ansible.com/ansible/latest/collections/ansible/builtin/file_module.html"><code>ansible.builtin.file</code></a> or <a href="https://example.com/?a=1&amp;b=2">docs</a>.`
+	if got := HTML(p); got != expected {
+		t.Errorf("unexpected HTML:\ngot  %q\nwant %q", got, expected)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("a verylongwordthatdoesnotfit b", 5)
+	expected := []string{"a", "verylongwordthatdoesnotfit", "b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
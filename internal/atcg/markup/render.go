@@ -0,0 +1,264 @@
+package markup
+
+import (
+	"html"
+	"strings"
+)
+
+// ANSI escape sequences used by the terminal renderer.
+const (
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+	ansiYellow    = "\x1b[33m"
+	ansiReset     = "\x1b[0m"
+)
+
+// Terminal renders a paragraph for display in a terminal. With color set,
+// markup is shown with ANSI styles; otherwise the paragraph is rendered as
+// Plain text.
+func Terminal(p Paragraph, color bool) string {
+	if !color {
+		return Plain(p)
+	}
+
+	var b strings.Builder
+	style := func(codes, s string) {
+		b.WriteString(codes + s + ansiReset)
+	}
+
+	for _, part := range p {
+		switch part.Kind {
+		case Text:
+			b.WriteString(part.Text)
+		case Italic:
+			style(ansiItalic, part.Text)
+		case Bold:
+			style(ansiBold, part.Text)
+		case Code, Value, EnvVar:
+			style(ansiCyan, part.Text)
+		case Option, ReturnValue:
+			style(ansiYellow, optionText(part))
+		case Module:
+			b.WriteString("[")
+			style(ansiBold, part.Text)
+			b.WriteString("]")
+		case Plugin:
+			b.WriteString("[")
+			style(ansiBold, part.Text)
+			b.WriteString("]")
+			if part.PluginType != "" {
+				b.WriteString(" (" + part.PluginType + ")")
+			}
+		case URL:
+			style(ansiUnderline, part.Target)
+		case Link:
+			b.WriteString(part.Text + " <")
+			style(ansiUnderline, part.Target)
+			b.WriteString(">")
+		case Ref:
+			b.WriteString(part.Text)
+		case HorizontalLine:
+			b.WriteString("\n" + strings.Repeat("-", 13) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Plain renders a paragraph as plain text. Emphasis is dropped; code-like
+// markup is wrapped in backticks so it stays recognisable.
+func Plain(p Paragraph) string {
+	var b strings.Builder
+
+	for _, part := range p {
+		switch part.Kind {
+		case Text, Italic, Bold, Ref:
+			b.WriteString(part.Text)
+		case Code, Value, EnvVar:
+			b.WriteString("`" + part.Text + "`")
+		case Option, ReturnValue:
+			b.WriteString("`" + optionText(part) + "`")
+		case Module:
+			b.WriteString("[" + part.Text + "]")
+		case Plugin:
+			b.WriteString("[" + part.Text + "]")
+			if part.PluginType != "" {
+				b.WriteString(" (" + part.PluginType + ")")
+			}
+		case URL:
+			b.WriteString(part.Target)
+		case Link:
+			b.WriteString(part.Text + " <" + part.Target + ">")
+		case HorizontalLine:
+			b.WriteString("\n" + strings.Repeat("-", 13) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// YAMLComment renders paragraphs as a YAML comment block. Every line starts
+// with indent and "# " and is wrapped to at most width columns where the
+// words allow; paragraphs are separated by an empty comment line.
+func YAMLComment(paragraphs []Paragraph, indent string, width int) string {
+	var b strings.Builder
+	for i, p := range paragraphs {
+		if i > 0 {
+			b.WriteString(indent + "#\n")
+		}
+		for _, line := range strings.Split(Plain(p), "\n") {
+			for _, wrapped := range wrap(line, width-len(indent)-2) {
+				b.WriteString(strings.TrimRight(indent+"# "+wrapped, " ") + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// HTML renders a paragraph as an HTML fragment.
+func HTML(p Paragraph) string {
+	var b strings.Builder
+	code := func(s string) {
+		b.WriteString("<code>" + html.EscapeString(s) + "</code>")
+	}
+	link := func(inner, url string) {
+		if url == "" {
+			b.WriteString(inner)
+			return
+		}
+		b.WriteString(`<a href="` + html.EscapeString(url) + `">` + inner + "</a>")
+	}
+
+	for _, part := range p {
+		switch part.Kind {
+		case Text:
+			b.WriteString(html.EscapeString(part.Text))
+		case Italic:
+			b.WriteString("<em>" + html.EscapeString(part.Text) + "</em>")
+		case Bold:
+			b.WriteString("<b>" + html.EscapeString(part.Text) + "</b>")
+		case Code, Value, EnvVar:
+			code(part.Text)
+		case Option, ReturnValue:
+			code(optionText(part))
+		case Module:
+			link("<code>"+html.EscapeString(part.Text)+"</code>", DocsURL(part.Text, "module"))
+		case Plugin:
+			link("<code>"+html.EscapeString(part.Text)+"</code>", DocsURL(part.Plugin, part.PluginType))
+		case URL:
+			link(html.EscapeString(part.Target), part.Target)
+		case Link:
+			link(html.EscapeString(part.Text), part.Target)
+		case Ref:
+			b.WriteString(html.EscapeString(part.Text))
+		case HorizontalLine:
+			b.WriteString("<hr>")
+		}
+	}
+
+	return b.String()
+}
+
+// Markdown renders a paragraph as CommonMark.
+func Markdown(p Paragraph) string {
+	var b strings.Builder
+
+	for _, part := range p {
+		switch part.Kind {
+		case Text:
+			b.WriteString(escapeMarkdown(part.Text))
+		case Italic:
+			b.WriteString("*" + escapeMarkdown(part.Text) + "*")
+		case Bold:
+			b.WriteString("**" + escapeMarkdown(part.Text) + "**")
+		case Code, Value, EnvVar:
+			b.WriteString(codeSpan(part.Text))
+		case Option, ReturnValue:
+			b.WriteString(codeSpan(optionText(part)))
+		case Module:
+			b.WriteString(markdownLink(part.Text, DocsURL(part.Text, "module")))
+		case Plugin:
+			b.WriteString(markdownLink(part.Text, DocsURL(part.Plugin, part.PluginType)))
+		case URL:
+			b.WriteString("<" + part.Target + ">")
+		case Link:
+			b.WriteString("[" + escapeMarkdown(part.Text) + "](" + part.Target + ")")
+		case Ref:
+			b.WriteString(escapeMarkdown(part.Text))
+		case HorizontalLine:
+			b.WriteString("\n\n---\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+// DocsURL returns the docs.ansible.com page of a plugin, or "" when the name
+// is not a fully qualified collection name.
+func DocsURL(fqcn, pluginType string) string {
+	parts := strings.Split(fqcn, ".")
+	if len(parts) < 3 || pluginType == "" {
+		return ""
+	}
+	return "https://docs.ansible.com/ansible/latest/collections/" + parts[0] + "/" + parts[1] + "/" +
+		strings.Join(parts[2:], ".") + "_" + pluginType + ".html"
+}
+
+// optionText renders O() and RV() as name or name=value.
+func optionText(part Part) string {
+	if part.HasValue {
+		return part.Text + "=" + part.Value
+	}
+	return part.Text
+}
+
+func markdownLink(text, url string) string {
+	if url == "" {
+		return codeSpan(text)
+	}
+	return "[" + codeSpan(text) + "](" + url + ")"
+}
+
+// codeSpan wraps s in enough backticks to hold any backticks it contains.
+func codeSpan(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// wrap splits s into lines of at most width bytes, breaking between words.
+// Words longer than width get a line of their own.
+func wrap(s string, width int) []string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	line := words[0]
+	for _, word := range words[1:] {
+		if width > 0 && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line += " " + word
+	}
+	return append(lines, line)
+}
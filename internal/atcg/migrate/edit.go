@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Edit replaces Old, found at a 1-based line and column as yaml.v3 reports
+// them, with New. Edits change nothing but the text they replace, so that
+// comments, key order and formatting survive.
+type Edit struct {
+	Line   int
+	Column int
+	Old    string
+	New    string
+}
+
+// NodeEdit returns the edit replacing old, which the scalar node starts
+// with, by new. Plain and quoted scalars can be edited; block scalars and
+// tagged values cannot.
+func NodeEdit(node *yaml.Node, old, new string) (Edit, error) {
+	if node.Kind != yaml.ScalarNode {
+		return Edit{}, fmt.Errorf("line %d: %s is not a scalar", node.Line, old)
+	}
+	edit := Edit{Line: node.Line, Column: node.Column, Old: old, New: new}
+	switch node.Style {
+	case 0:
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		edit.Column++
+	default:
+		return Edit{}, fmt.Errorf("line %d: %s is not a plain or quoted scalar", node.Line, old)
+	}
+	return edit, nil
+}
+
+// Apply returns content with edits applied. Every edit must find its Old
+// text at its position, or nothing is changed.
+func Apply(content []byte, edits []Edit) ([]byte, error) {
+	lines := lineOffsets(content)
+	type splice struct {
+		start int
+		edit  Edit
+	}
+	splices := make([]splice, 0, len(edits))
+	for _, edit := range edits {
+		start, ok := offset(content, lines, edit.Line, edit.Column)
+		if !ok || !bytes.HasPrefix(content[start:], []byte(edit.Old)) {
+			return nil, fmt.Errorf("line %d, column %d: %s not found", edit.Line, edit.Column, edit.Old)
+		}
+		splices = append(splices, splice{start, edit})
+	}
+	sort.Slice(splices, func(i, j int) bool { return splices[i].start < splices[j].start })
+
+	var out bytes.Buffer
+	last := 0
+	for _, s := range splices {
+		if s.start < last {
+			return nil, fmt.Errorf("line %d, column %d: overlapping edits", s.edit.Line, s.edit.Column)
+		}
+		out.Write(content[last:s.start])
+		out.WriteString(s.edit.New)
+		last = s.start + len(s.edit.Old)
+	}
+	out.Write(content[last:])
+	return out.Bytes(), nil
+}
+
+// lineOffsets returns the byte offset each line starts at.
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// offset converts a line and a column counted in characters to a byte
+// offset.
+func offset(content []byte, lines []int, line, column int) (int, bool) {
+	if line < 1 || line > len(lines) || column < 1 {
+		return 0, false
+	}
+	pos := lines[line-1]
+	for i := 1; i < column; i++ {
+		if pos >= len(content) || content[pos] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(content[pos:])
+		pos += size
+	}
+	return pos, true
+}
@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestApply(t *testing.T) {
+	content := []byte("- name: Café\n  copy: {src: a}  # keep\n- \"win_ping\": {}\n")
+	edits := []Edit{
+		{Line: 3, Column: 4, Old: "win_ping", New: "ansible.windows.win_ping"},
+		{Line: 2, Column: 3, Old: "copy", New: "ansible.builtin.copy"},
+	}
+	got, err := Apply(content, edits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "- name: Café\n  ansible.builtin.copy: {src: a}  # keep\n- \"ansible.windows.win_ping\": {}\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestApply_Mismatch(t *testing.T) {
+	content := []byte("- copy: {}\n")
+	for _, edit := range []Edit{
+		{Line: 1, Column: 3, Old: "shell", New: "x"},
+		{Line: 2, Column: 1, Old: "copy", New: "x"},
+		{Line: 1, Column: 40, Old: "copy", New: "x"},
+	} {
+		if _, err := Apply(content, []Edit{edit}); err == nil {
+			t.Errorf("expected an error for %+v", edit)
+		}
+	}
+}
+
+func TestApply_MultibyteColumns(t *testing.T) {
+	content := []byte("- {name: Žluť, copy: {}}\n")
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		t.Fatal(err)
+	}
+	key := root.Content[0].Content[0].Content[2]
+	edit, err := NodeEdit(key, "copy", "ansible.builtin.copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Apply(content, []Edit{edit})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(got), "Žluť, ansible.builtin.copy: {}") {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestNodeEdit(t *testing.T) {
+	var root yaml.Node
+	content := "plain: x\n'single': x\nblock: |\n  copy\n"
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		t.Fatal(err)
+	}
+	mapping := root.Content[0]
+
+	if edit, err := NodeEdit(mapping.Content[0], "plain", "y"); err != nil || edit.Column != 1 {
+		t.Errorf("plain: got %+v, %v", edit, err)
+	}
+	if edit, err := NodeEdit(mapping.Content[2], "single", "y"); err != nil || edit.Column != 2 {
+		t.Errorf("quoted: got %+v, %v", edit, err)
+	}
+	if _, err := NodeEdit(mapping.Content[5], "copy", "y"); err == nil {
+		t.Error("expected an error for a block scalar")
+	}
+}
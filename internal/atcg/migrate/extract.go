@@ -0,0 +1,441 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// Invocation is a task calling a module, turned into a loop item.
+type Invocation struct {
+	File string
+	// Line is the line the task starts on.
+	Line int
+	// Name is the name of the task, kept as a comment on its item.
+	Name string
+	Task *yaml.Node
+	// Item holds the arguments of the task by option name, and its task
+	// keywords below atcgTasks.TaskKey.
+	Item *yaml.Node
+}
+
+// Run is a sequence of adjacent tasks in one task list that call the same
+// module. Running the generated task file over their items does what the
+// tasks did, in the same order.
+type Run struct {
+	File        string
+	Module      string
+	Tasks       *yaml.Node
+	Invocations []Invocation
+}
+
+// Extractor finds runs of tasks that generated tasks can replace.
+type Extractor struct {
+	// Lookup resolves the module names of calls.
+	Lookup Lookup
+	// Docs returns the documentation of a module by FQCN.
+	Docs func(module string) (*atcgModules.ModuleDoc, error)
+	// Keywords lists the task keywords items may carry, as
+	// atcgTasks.Options.Keywords. Tasks using other keywords are left
+	// alone.
+	Keywords []string
+	// Modules limits extraction to these FQCNs. Empty means every module.
+	Modules []string
+	// Min is the shortest run extracted. Zero means 2.
+	Min int
+}
+
+// Runs returns the runs of a playbook or task file, and the tasks calling
+// a module that has a run, or was asked for, which could not be turned
+// into items. collections is the search list of the enclosing role, as
+// for atcgLint.Calls.
+func (e *Extractor) Runs(file string, content []byte, collections []string) ([]Run, []Ambiguity) {
+	min := e.Min
+	if min == 0 {
+		min = 2
+	}
+
+	var runs []Run
+	var skipped []Ambiguity
+	skippedModules := make(map[string]bool)
+	var current *Run
+	previous := -1
+	for _, call := range atcgLint.Actions(file, content, collections) {
+		module, _, err := e.Lookup(call)
+		if err != nil || !e.selected(module) {
+			continue
+		}
+		inv, err := e.invocation(call, module)
+		if err != nil {
+			skipped = append(skipped, Ambiguity{File: file, Line: call.Line, Column: call.Column, Module: module, Reason: err.Error()})
+			skippedModules[module] = true
+			continue
+		}
+
+		index := indexOf(call.Tasks, call.Task)
+		if current == nil || current.Tasks != call.Tasks || current.Module != module || index != previous+1 {
+			runs = append(runs, Run{File: file, Module: module, Tasks: call.Tasks})
+			current = &runs[len(runs)-1]
+		}
+		current.Invocations = append(current.Invocations, inv)
+		previous = index
+	}
+
+	kept := runs[:0]
+	extracted := make(map[string]bool)
+	for _, run := range runs {
+		if len(run.Invocations) >= min {
+			kept = append(kept, run)
+			extracted[run.Module] = true
+		}
+	}
+	reported := skipped[:0]
+	for _, s := range skipped {
+		if extracted[s.Module] || len(e.Modules) > 0 {
+			reported = append(reported, s)
+		}
+	}
+	return kept, reported
+}
+
+func (e *Extractor) selected(module string) bool {
+	if len(e.Modules) == 0 {
+		return true
+	}
+	for _, m := range e.Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// invocation turns a call into a loop item, or explains why it cannot be
+// one.
+func (e *Extractor) invocation(call atcgLint.Call, module string) (Invocation, error) {
+	inv := Invocation{File: call.File, Line: call.Task.Line, Task: call.Task}
+	doc, err := e.Docs(module)
+	if err != nil {
+		return inv, err
+	}
+	args, ok := call.Arguments()
+	if !ok {
+		return inv, fmt.Errorf("free-form arguments cannot be turned into an item")
+	}
+
+	aliases := make(map[string]string)
+	for name, option := range doc.Options {
+		for _, alias := range option.Aliases {
+			aliases[alias] = name
+		}
+	}
+	item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(args.Content); i += 2 {
+		key, value := args.Content[i], args.Content[i+1]
+		name := key.Value
+		if _, ok := doc.Options[name]; !ok {
+			canonical, ok := aliases[name]
+			if !ok {
+				return inv, fmt.Errorf("%s has no option %s", module, name)
+			}
+			renamed := *key
+			renamed.Value = canonical
+			key, name = &renamed, canonical
+		}
+		if seen[name] {
+			return inv, fmt.Errorf("option %s is set twice", name)
+		}
+		seen[name] = true
+		item.Content = append(item.Content, key, value)
+	}
+
+	keywords := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(call.Task.Content); i += 2 {
+		key, value := call.Task.Content[i], call.Task.Content[i+1]
+		switch {
+		case key.Value == "local_action":
+			return inv, fmt.Errorf("local_action cannot be passed through items")
+		case key == call.Name || key.Value == "action" || key.Value == "args":
+			// The action and its arguments.
+		case key.Value == "name":
+			inv.Name = value.Value
+		case contains(e.Keywords, key.Value):
+			if atcgTasks.IsConditional(key.Value) {
+				if value = condition(value); value == nil {
+					return inv, fmt.Errorf("%s must be a condition or a list of conditions", key.Value)
+				}
+			}
+			keywords.Content = append(keywords.Content, key, value)
+		default:
+			return inv, fmt.Errorf("task keyword %s cannot be passed through items (allowed: %s)", key.Value, allowed(e.Keywords))
+		}
+	}
+	if len(keywords.Content) > 0 {
+		item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: atcgTasks.TaskKey}, keywords)
+	}
+	inv.Item = item
+	return inv, nil
+}
+
+// condition returns the value a conditional task keyword takes in an item.
+// A task may write its conditions bare, but an item holding a bare condition
+// holds a string, which is always true, so they are wrapped in "{{ }}".
+// Booleans and templates are kept. It returns nil for any other value.
+func condition(value *yaml.Node) *yaml.Node {
+	var conditions []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag == "!!bool" || strings.Contains(value.Value, "{{") {
+			return value
+		}
+		conditions = []string{value.Value}
+	case yaml.SequenceNode:
+		for _, child := range value.Content {
+			if child.Kind != yaml.ScalarNode || strings.Contains(child.Value, "{{") {
+				return nil
+			}
+			conditions = append(conditions, child.Value)
+		}
+		if len(conditions) == 0 {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: atcgTasks.Condition(conditions), Style: yaml.DoubleQuotedStyle, Line: value.Line, Column: value.Column}
+}
+
+func allowed(keywords []string) string {
+	if len(keywords) == 0 {
+		return "none, see --keywords"
+	}
+	return strings.Join(keywords, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOf(list, node *yaml.Node) int {
+	for i, child := range list.Content {
+		if child == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// Items returns the loop items of runs in the shape the generated main.yml
+// loops over: a list, or with atcgTasks.LoopDict a mapping keyed by the key
+// option. Each item is commented with the task it came from.
+func Items(runs []Run, inputs atcgTasks.Inputs) (*yaml.Node, error) {
+	switch inputs.Loop {
+	case atcgTasks.LoopSingle:
+		return nil, fmt.Errorf("tasks cannot be extracted with the single loop strategy, which has no items")
+	case atcgTasks.LoopDict:
+		if inputs.KeyOption == "" {
+			return nil, fmt.Errorf("no key option to key the items of %s by", inputs.Variable)
+		}
+	}
+
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	dict := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	keys := make(map[string]string)
+	for _, run := range runs {
+		for _, inv := range run.Invocations {
+			comment := fmt.Sprintf("%s:%d", inv.File, inv.Line)
+			if inv.Name != "" {
+				comment = inv.Name + " (" + comment + ")"
+			}
+			if inputs.Loop != atcgTasks.LoopDict {
+				item := *inv.Item
+				item.HeadComment = comment
+				list.Content = append(list.Content, &item)
+				continue
+			}
+
+			item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			var key *yaml.Node
+			for i := 0; i+1 < len(inv.Item.Content); i += 2 {
+				if inv.Item.Content[i].Value == inputs.KeyOption {
+					key = inv.Item.Content[i+1]
+					continue
+				}
+				item.Content = append(item.Content, inv.Item.Content[i], inv.Item.Content[i+1])
+			}
+			switch {
+			case key == nil || key.Kind != yaml.ScalarNode:
+				return nil, fmt.Errorf("%s: %s must be set to a plain value to key the item by", comment, inputs.KeyOption)
+			case keys[key.Value] != "":
+				return nil, fmt.Errorf("%s: %s %s is also used at %s", comment, inputs.KeyOption, key.Value, keys[key.Value])
+			}
+			keys[key.Value] = comment
+			dictKey := *key
+			dictKey.HeadComment = comment
+			dict.Content = append(dict.Content, &dictKey, item)
+		}
+	}
+	if inputs.Loop == atcgTasks.LoopDict {
+		return dict, nil
+	}
+	return list, nil
+}
+
+// Variable is a top-level variable of a vars file.
+type Variable struct {
+	Name    string
+	Value   *yaml.Node
+	Comment string
+}
+
+// EncodeVars renders variables as a YAML vars file.
+func EncodeVars(variables []Variable) ([]byte, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, v := range variables {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Name, HeadComment: v.Comment}, v.Value)
+	}
+	var b strings.Builder
+	b.WriteString("---\n")
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// Replace returns content with the tasks of each run replaced by the text
+// include returns for it, indented to the run. Comments before and after
+// the run are kept.
+func Replace(content []byte, runs []Run, include func(Run) (string, error)) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+
+	type span struct {
+		start, end int // 0-based, end exclusive
+		text       string
+	}
+	var spans []span
+	for _, run := range runs {
+		first := run.Invocations[0].Task
+		last := run.Invocations[len(run.Invocations)-1].Task
+		start := first.Line - 1
+		if first.Style&yaml.FlowStyle != 0 || run.Tasks.Style&yaml.FlowStyle != 0 || start >= len(lines) {
+			return nil, fmt.Errorf("%s:%d: tasks in flow style cannot be replaced", run.File, first.Line)
+		}
+		dash := first.Column - 3
+		if dash < 0 || len(lines[start]) < first.Column || strings.TrimSpace(lines[start][:dash]) != "" || lines[start][dash:dash+2] != "- " {
+			return nil, fmt.Errorf("%s:%d: the task does not start on the line of its dash", run.File, first.Line)
+		}
+		indent := lines[start][:dash]
+
+		end := nextLine(&root, last) - 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for i := last.Line; i < end; i++ {
+			if strings.HasPrefix(lines[i], "---") || strings.HasPrefix(lines[i], "...") {
+				end = i
+				break
+			}
+		}
+		for end > last.Line {
+			line := lines[end-1]
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !(strings.HasPrefix(trimmed, "#") && len(line)-len(strings.TrimLeft(line, " ")) <= dash) {
+				break
+			}
+			end--
+		}
+
+		text, err := include(run)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		for _, line := range strings.Split(strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(text, "---\n"), atcgTasks.Marker+"\n"), "\n"), "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			b.WriteString(indent + line + "\n")
+		}
+		spans = append(spans, span{start, end, b.String()})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var out strings.Builder
+	next := 0
+	for _, s := range spans {
+		if s.start < next {
+			return nil, fmt.Errorf("overlapping runs at line %d", s.start+1)
+		}
+		out.WriteString(strings.Join(lines[next:s.start], ""))
+		out.WriteString(s.text)
+		next = s.end
+	}
+	out.WriteString(strings.Join(lines[next:], ""))
+	return []byte(out.String()), nil
+}
+
+// nextLine returns the line of the first node after the node of root at
+// the position of node and its descendants, or a line past the end of the
+// document.
+func nextLine(root, node *yaml.Node) int {
+	var find func(n *yaml.Node) *yaml.Node
+	find = func(n *yaml.Node) *yaml.Node {
+		if n.Kind == node.Kind && n.Line == node.Line && n.Column == node.Column {
+			return n
+		}
+		for _, child := range n.Content {
+			if found := find(child); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if found := find(root); found != nil {
+		node = found
+	}
+
+	inside := make(map[*yaml.Node]bool)
+	var mark func(n *yaml.Node)
+	mark = func(n *yaml.Node) {
+		inside[n] = true
+		for _, child := range n.Content {
+			mark(child)
+		}
+	}
+	mark(node)
+
+	next := int(^uint(0) >> 1)
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if !inside[n] && n.Kind != yaml.DocumentNode && n.Line > node.Line && n.Line < next {
+			next = n.Line
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+	return next
+}
@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+const extractPlaybook = `---
+- hosts: windows
+  tasks:
+    - name: Debug first
+      ansible.builtin.debug: {msg: start}
+
+    # Rights
+    - name: Allow logon
+      win_user_right:
+        name: SeInteractiveLogonRight
+        users: [Administrators]  # admins only
+      become: true
+    - name: Deny network
+      ansible.windows.win_user_right:
+        name: SeDenyNetworkLogonRight
+        user: [Guests]
+        action: add
+    # next section
+
+    - name: Debug last
+      ansible.builtin.debug: {msg: end}
+    - ansible.windows.win_user_right:
+        name: SeBackupPrivilege
+        users: []
+      register: out
+`
+
+func testExtractor(modules ...string) *Extractor {
+	return &Extractor{
+		Lookup: func(call atcgLint.Call) (string, []string, error) {
+			if strings.HasSuffix(call.Module, "win_user_right") {
+				return "ansible.windows.win_user_right", nil, nil
+			}
+			if strings.HasSuffix(call.Module, "debug") {
+				return "ansible.builtin.debug", nil, nil
+			}
+			return "", nil, errors.New("not found")
+		},
+		Docs: func(module string) (*atcgModules.ModuleDoc, error) {
+			if module == "ansible.builtin.debug" {
+				return &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"msg": {}}}, nil
+			}
+			return &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+				"name":   {Required: true},
+				"users":  {Aliases: []string{"user"}},
+				"action": {},
+			}}, nil
+		},
+		Keywords: []string{"become"},
+		Modules:  modules,
+	}
+}
+
+func TestExtractor_Runs(t *testing.T) {
+	runs, skipped := testExtractor().Runs("site.yml", []byte(extractPlaybook), nil)
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %+v", runs)
+	}
+	run := runs[0]
+	if run.Module != "ansible.windows.win_user_right" || len(run.Invocations) != 2 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if run.Invocations[0].Name != "Allow logon" || run.Invocations[0].Line != 8 || run.Invocations[1].Line != 13 {
+		t.Errorf("unexpected invocations %+v", run.Invocations)
+	}
+	if len(skipped) != 1 || skipped[0].Line != 22 || !strings.Contains(skipped[0].Reason, "register") {
+		t.Errorf("unexpected skipped %+v", skipped)
+	}
+
+	items, err := Items(runs, atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopList})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := EncodeVars([]Variable{{Name: "win_user_right", Value: items, Comment: "Extracted by atcg"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `---
+# Extracted by atcg
+win_user_right:
+  # Allow logon (site.yml:8)
+  - name: SeInteractiveLogonRight
+    users: [Administrators] # admins only
+    _task:
+      become: true
+  # Deny network (site.yml:13)
+  - name: SeDenyNetworkLogonRight
+    users: [Guests]
+    action: add
+`
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestExtractor_RunsOfSelectedModules(t *testing.T) {
+	runs, skipped := testExtractor("ansible.builtin.debug").Runs("site.yml", []byte(extractPlaybook), nil)
+	if len(runs) != 0 || len(skipped) != 0 {
+		t.Errorf("expected nothing, got %+v, %+v", runs, skipped)
+	}
+	e := testExtractor("ansible.builtin.debug")
+	e.Min = 1
+	if runs, _ := e.Runs("site.yml", []byte(extractPlaybook), nil); len(runs) != 2 {
+		t.Errorf("expected 2 runs of 1, got %+v", runs)
+	}
+}
+
+func TestItems_Dict(t *testing.T) {
+	runs, _ := testExtractor().Runs("site.yml", []byte(extractPlaybook), nil)
+	items, err := Items(runs, atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopDict, KeyOption: "name"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, _ := EncodeVars([]Variable{{Name: "win_user_right", Value: items}})
+	if !strings.Contains(string(out), "  # Deny network (site.yml:13)\n  SeDenyNetworkLogonRight:\n    users: [Guests]\n") {
+		t.Errorf("unexpected dict items\n%s", out)
+	}
+
+	if _, err := Items(runs, atcgTasks.Inputs{Loop: atcgTasks.LoopSingle}); err == nil {
+		t.Error("expected an error for the single loop")
+	}
+	runs[0].Invocations[1].Item.Content[1].Value = "SeInteractiveLogonRight"
+	if _, err := Items(runs, atcgTasks.Inputs{Loop: atcgTasks.LoopDict, KeyOption: "name"}); err == nil || !strings.Contains(err.Error(), "also used") {
+		t.Errorf("expected a duplicate key error, got %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	runs, _ := testExtractor().Runs("site.yml", []byte(extractPlaybook), nil)
+	got, err := Replace([]byte(extractPlaybook), runs, func(run Run) (string, error) {
+		return "---\n" + atcgTasks.Marker + "\n- name: Configure win_user_right\n  ansible.builtin.include_tasks:\n    file: tasks/win_user_right.yml\n  loop: \"{{ win_user_right }}\"\n", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `---
+- hosts: windows
+  tasks:
+    - name: Debug first
+      ansible.builtin.debug: {msg: start}
+
+    # Rights
+    - name: Configure win_user_right
+      ansible.builtin.include_tasks:
+        file: tasks/win_user_right.yml
+      loop: "{{ win_user_right }}"
+    # next section
+
+    - name: Debug last
+      ansible.builtin.debug: {msg: end}
+    - ansible.windows.win_user_right:
+        name: SeBackupPrivilege
+        users: []
+      register: out
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReplace_EndOfFile(t *testing.T) {
+	content := "- win_user_right: {name: a}\n- win_user_right:\n    name: b\n# trailing\n"
+	runs, _ := testExtractor().Runs("t.yml", []byte(content), nil)
+	got, err := Replace([]byte(content), runs, func(Run) (string, error) { return "- include: x\n", nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "- include: x\n# trailing\n" {
+		t.Errorf("unexpected result %q", got)
+	}
+}
+
+func TestExtractor_Conditions(t *testing.T) {
+	e := testExtractor()
+	e.Keywords = []string{"when", "changed_when"}
+	playbook := `---
+- hosts: windows
+  tasks:
+    - name: Allow logon
+      win_user_right: {name: SeInteractiveLogonRight}
+      when: ansible_os_family == 'Windows'
+      changed_when: false
+    - name: Deny network
+      win_user_right: {name: SeDenyNetworkLogonRight}
+      when:
+        - ansible_os_family == 'Windows'
+        - deny | bool
+      changed_when: "{{ out.changed }}"
+`
+	runs, skipped := e.Runs("site.yml", []byte(playbook), nil)
+	if len(runs) != 1 || len(skipped) != 0 {
+		t.Fatalf("unexpected runs %+v, skipped %+v", runs, skipped)
+	}
+	items, err := Items(runs, atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopList})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := EncodeVars([]Variable{{Name: "win_user_right", Value: items}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`when: "{{ ansible_os_family == 'Windows' }}"`,
+		`when: "{{ (ansible_os_family == 'Windows') and (deny | bool) }}"`,
+		"changed_when: false",
+		`changed_when: "{{ out.changed }}"`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %s in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package migrate
+
+import (
+	atcgLint "atcg/internal/atcg/lint"
+)
+
+// Lookup returns the FQCN a module call should use. Calls it cannot decide
+// on are reported with an error and the modules the name might stand for.
+type Lookup func(call atcgLint.Call) (name string, candidates []string, err error)
+
+// Change is a module name rewritten to its FQCN.
+type Change struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// Ambiguity is a module call left as it is because it needs a human
+// decision.
+type Ambiguity struct {
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	Module     string   `json:"module"`
+	Reason     string   `json:"reason"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// Report lists what a migration changed and what it left for a human.
+type Report struct {
+	Changes     []Change    `json:"changes"`
+	Ambiguities []Ambiguity `json:"ambiguities"`
+}
+
+// Add appends the entries of other.
+func (r *Report) Add(other Report) {
+	r.Changes = append(r.Changes, other.Changes...)
+	r.Ambiguities = append(r.Ambiguities, other.Ambiguities...)
+}
+
+// FQCN rewrites the module names in a playbook or task file to the names
+// lookup returns, leaving everything else in the file untouched. collections
+// is the search list of the enclosing role, as for atcgLint.Calls.
+func FQCN(file string, content []byte, collections []string, lookup Lookup) ([]byte, Report, error) {
+	var report Report
+	var edits []Edit
+	for _, call := range atcgLint.Actions(file, content, collections) {
+		name, candidates, err := lookup(call)
+		if err != nil {
+			report.Ambiguities = append(report.Ambiguities, Ambiguity{
+				File: file, Line: call.Line, Column: call.Column, Module: call.Module,
+				Reason: err.Error(), Candidates: candidates,
+			})
+			continue
+		}
+		if name == call.Module {
+			continue
+		}
+		edit, err := NodeEdit(call.Name, call.Module, name)
+		if err != nil {
+			report.Ambiguities = append(report.Ambiguities, Ambiguity{
+				File: file, Line: call.Line, Column: call.Column, Module: call.Module,
+				Reason: "cannot be rewritten in place: " + err.Error(), Candidates: []string{name},
+			})
+			continue
+		}
+		edits = append(edits, edit)
+		report.Changes = append(report.Changes, Change{File: file, Line: edit.Line, Column: edit.Column, Old: call.Module, New: name})
+	}
+
+	out, err := Apply(content, edits)
+	if err != nil {
+		return nil, Report{}, err
+	}
+	return out, report, nil
+}
@@ -0,0 +1,92 @@
+package migrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	atcgLint "atcg/internal/atcg/lint"
+)
+
+func TestFQCN(t *testing.T) {
+	content := []byte(`---
+# Site playbook
+- hosts: all
+  collections: [ansible.windows]
+  tasks:
+    - name: Grant   # rights
+      win_user_right:
+        name: x
+    - local_action: copy src=a dest=b
+    - action:
+        module: ansible.builtin.debug
+    - include_tasks: other.yml
+    - mystery: {}
+    - shell: >
+        echo hi
+`)
+	known := map[string]string{
+		"win_user_right":        "ansible.windows.win_user_right",
+		"copy":                  "ansible.builtin.copy",
+		"ansible.builtin.debug": "ansible.builtin.debug",
+		"include_tasks":         "ansible.builtin.include_tasks",
+		"shell":                 "ansible.builtin.shell",
+	}
+	lookup := func(call atcgLint.Call) (string, []string, error) {
+		if name, ok := known[call.Module]; ok {
+			return name, nil, nil
+		}
+		return "", []string{"a.b.mystery", "c.d.mystery"}, errors.New("not found")
+	}
+
+	got, report, err := FQCN("site.yml", content, nil, lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `---
+# Site playbook
+- hosts: all
+  collections: [ansible.windows]
+  tasks:
+    - name: Grant   # rights
+      ansible.windows.win_user_right:
+        name: x
+    - local_action: ansible.builtin.copy src=a dest=b
+    - action:
+        module: ansible.builtin.debug
+    - ansible.builtin.include_tasks: other.yml
+    - mystery: {}
+    - ansible.builtin.shell: >
+        echo hi
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	var changes []string
+	for _, c := range report.Changes {
+		changes = append(changes, c.Old+"->"+c.New)
+	}
+	wantChanges := []string{"win_user_right->ansible.windows.win_user_right", "copy->ansible.builtin.copy", "include_tasks->ansible.builtin.include_tasks", "shell->ansible.builtin.shell"}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes = %v, want %v", changes, wantChanges)
+	}
+	if len(report.Ambiguities) != 1 || report.Ambiguities[0].Module != "mystery" || report.Ambiguities[0].Line != 13 || len(report.Ambiguities[0].Candidates) != 2 {
+		t.Errorf("unexpected ambiguities %+v", report.Ambiguities)
+	}
+}
+
+func TestFQCN_BlockScalarAction(t *testing.T) {
+	content := []byte("- local_action: |\n    copy src=a dest=b\n")
+	lookup := func(call atcgLint.Call) (string, []string, error) { return "ansible.builtin.copy", nil, nil }
+	got, report, err := FQCN("t.yml", content, nil, lookup)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("content changed: %q", got)
+	}
+	if len(report.Ambiguities) != 1 || report.Ambiguities[0].Candidates[0] != "ansible.builtin.copy" {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
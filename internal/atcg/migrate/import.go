@@ -0,0 +1,434 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// Table is tabular data, such as a spreadsheet exported as CSV: named
+// columns and one row per loop item.
+type Table struct {
+	File string
+	// Line is the line of the header row, or of the first record in JSON.
+	Line    int
+	Columns []string
+	Rows    []Row
+}
+
+// Row is a row of a table. Values holds a node per column, nil for an
+// empty or missing cell.
+type Row struct {
+	Line   int
+	Values []*yaml.Node
+}
+
+// ReadCSV reads a CSV file whose first row names the columns. comma is the
+// field delimiter; 0 picks the most frequent of comma, semicolon and tab in
+// the header, as spreadsheets export with either depending on the locale.
+// Blank rows are skipped.
+func ReadCSV(file string, content []byte, comma rune) (*Table, error) {
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	if comma == 0 {
+		comma = delimiter(content)
+	}
+	r := csv.NewReader(bytes.NewReader(content))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	table := &Table{File: file}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		line, _ := r.FieldPos(0)
+		if table.Columns == nil {
+			table.Line = line
+			for _, column := range record {
+				table.Columns = append(table.Columns, strings.TrimSpace(column))
+			}
+			continue
+		}
+
+		row := Row{Line: line, Values: make([]*yaml.Node, len(table.Columns))}
+		blank := true
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			if i >= len(table.Columns) {
+				return nil, fmt.Errorf("%s:%d: the row has more cells than the header has columns", file, line)
+			}
+			row.Values[i] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: cell}
+			blank = false
+		}
+		if !blank {
+			table.Rows = append(table.Rows, row)
+		}
+	}
+	if table.Columns == nil {
+		return nil, fmt.Errorf("%s: no header row", file)
+	}
+	return table, nil
+}
+
+// delimiter returns the field delimiter used most in the first line.
+func delimiter(content []byte) rune {
+	header, _, _ := bytes.Cut(content, []byte("\n"))
+	best, count := ',', bytes.Count(header, []byte(","))
+	for _, comma := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(comma))); n > count {
+			best, count = comma, n
+		}
+	}
+	return best
+}
+
+// ReadJSON reads a JSON list of objects, as spreadsheets and most tools
+// export records. The columns are the keys of the objects, in the order
+// they first appear; null values count as empty cells.
+func ReadJSON(file string, content []byte) (*Table, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: expected a list of objects", file)
+	}
+
+	table := &Table{File: file, Line: root.Content[0].Line}
+	index := make(map[string]int)
+	for n, record := range root.Content[0].Content {
+		if record.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s:%d: record %d is not an object", file, record.Line, n+1)
+		}
+		if n == 0 {
+			table.Line = record.Line
+		}
+		row := Row{Line: record.Line}
+		for i := 0; i+1 < len(record.Content); i += 2 {
+			key, value := record.Content[i].Value, record.Content[i+1]
+			if _, ok := index[key]; !ok {
+				index[key] = len(table.Columns)
+				table.Columns = append(table.Columns, key)
+			}
+			if value.Tag == "!!null" {
+				continue
+			}
+			for len(row.Values) <= index[key] {
+				row.Values = append(row.Values, nil)
+			}
+			row.Values[index[key]] = value
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	for i := range table.Rows {
+		for len(table.Rows[i].Values) < len(table.Columns) {
+			table.Rows[i].Values = append(table.Rows[i].Values, nil)
+		}
+	}
+	return table, nil
+}
+
+// CellError is a column or cell of a table that cannot be imported.
+type CellError struct {
+	File   string
+	Line   int
+	Column string
+	Reason string
+	// Header is set for columns that match no option, rather than cells.
+	Header bool
+}
+
+func (e CellError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+	}
+	return fmt.Sprintf("%s:%d: column %q: %s", e.File, e.Line, e.Column, e.Reason)
+}
+
+// Importer turns the rows of a table into the loop items of a module.
+type Importer struct {
+	Module string
+	Doc    *atcgModules.ModuleDoc
+	Inputs atcgTasks.Inputs
+	// Columns maps column headers to option names. Other headers are
+	// matched against the option names and aliases, ignoring case and
+	// treating spaces and dashes as underscores. A column mapped to "" is
+	// skipped.
+	Columns map[string]string
+	// Separator splits the cells of list options; "" means a comma.
+	Separator string
+}
+
+// Import returns the loop items of the rows of table, in the shape the
+// generated main.yml loops over, and the cells that do not hold a valid
+// value. Each item is commented with the row it came from. Values are
+// converted to the type of their option; templated values are kept as they
+// are.
+func (im *Importer) Import(table *Table) (*yaml.Node, []CellError, error) {
+	if im.Inputs.Loop == atcgTasks.LoopSingle {
+		return nil, nil, fmt.Errorf("loop items cannot be imported with the single loop strategy, which has no items")
+	}
+	options, problems := im.columns(table)
+	if len(problems) > 0 {
+		return nil, problems, nil
+	}
+
+	run := Run{File: table.File, Module: im.Module}
+	for _, row := range table.Rows {
+		item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		set := make(map[string]bool)
+		for i, name := range options {
+			if name == "" || row.Values[i] == nil {
+				continue
+			}
+			option := im.Doc.Options[name]
+			value, err := im.convert(option, row.Values[i])
+			if err != nil {
+				problems = append(problems, CellError{File: table.File, Line: row.Line, Column: table.Columns[i], Reason: err.Error()})
+				continue
+			}
+			item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+			set[name] = true
+		}
+		for _, name := range atcgModules.OptionNames(im.Doc.Options) {
+			if (im.Doc.Options[name].Required || name == im.Inputs.KeyOption) && !set[name] {
+				problems = append(problems, CellError{File: table.File, Line: row.Line, Reason: fmt.Sprintf("required option %s is not set", name)})
+			}
+		}
+		run.Invocations = append(run.Invocations, Invocation{File: table.File, Line: row.Line, Item: item})
+	}
+	if len(problems) > 0 {
+		return nil, problems, nil
+	}
+	items, err := Items([]Run{run}, im.Inputs)
+	return items, nil, err
+}
+
+// columns returns the option each column holds, "" for skipped columns.
+func (im *Importer) columns(table *Table) ([]string, []CellError) {
+	names := atcgModules.OptionNames(im.Doc.Options)
+	options := make([]string, len(table.Columns))
+	used := make(map[string]string)
+	var problems []CellError
+	for i, column := range table.Columns {
+		problem := func(format string, args ...interface{}) {
+			problems = append(problems, CellError{File: table.File, Line: table.Line, Column: column, Reason: fmt.Sprintf(format, args...), Header: true})
+		}
+		name, mapped := im.Columns[column]
+		if mapped && name == "" {
+			continue
+		}
+		if !mapped {
+			name = normalizeHeader(column)
+		}
+		option := im.option(name)
+		switch {
+		case option == "" && mapped:
+			problem("is mapped to %s, which is not an option of %s", name, im.Module)
+			continue
+		case option == "":
+			if suggestion := atcgLint.Suggest(name, names); suggestion != "" {
+				problem("matches no option of %s, did you mean %s?", im.Module, suggestion)
+			} else {
+				problem("matches no option of %s", im.Module)
+			}
+			continue
+		case used[option] != "":
+			problem("holds %s, as column %q does", option, used[option])
+			continue
+		}
+		used[option] = column
+		options[i] = option
+	}
+	// Dict items are keyed by the key option, so no row can do without it.
+	if key := im.Inputs.KeyOption; im.Inputs.Loop == atcgTasks.LoopDict && key != "" && used[key] == "" {
+		problems = append(problems, CellError{File: table.File, Line: table.Line, Reason: fmt.Sprintf("no column holds %s, which keys the items with the dict loop", key), Header: true})
+	}
+	return options, problems
+}
+
+// option returns the option name is the name or an alias of, or "".
+func (im *Importer) option(name string) string {
+	if _, ok := im.Doc.Options[name]; ok {
+		return name
+	}
+	for _, candidate := range atcgModules.OptionNames(im.Doc.Options) {
+		if contains(im.Doc.Options[candidate].Aliases, name) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// normalizeHeader turns a column header such as "User Name" into the
+// option name it most likely stands for, user_name.
+func normalizeHeader(header string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(header)))
+}
+
+// convert returns value as a value of option: cells are parsed by the
+// option type, lists split on the separator or read as a JSON list, and
+// dicts read as a JSON object. The result is checked against the choices.
+func (im *Importer) convert(option atcgModules.ModuleOption, value *yaml.Node) (*yaml.Node, error) {
+	if value.Kind == yaml.ScalarNode && atcgLint.IsTemplated(value.Value) {
+		return value, nil
+	}
+	text := value.Kind == yaml.ScalarNode && value.Tag == "!!str"
+
+	var converted *yaml.Node
+	switch option.Type {
+	case "bool":
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s is not a boolean", describeValue(value))
+		}
+		b, ok := atcgLint.ParseBool(value.Value)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a boolean", value.Value)
+		}
+		converted = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+	case "int":
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s is not an integer", describeValue(value))
+		}
+		n, err := strconv.ParseInt(value.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", value.Value)
+		}
+		converted = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(n, 10)}
+	case "float":
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s is not a number", describeValue(value))
+		}
+		f, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value.Value)
+		}
+		converted = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(f, 'g', -1, 64)}
+	case "list":
+		list := value
+		if text {
+			var err error
+			if list, err = im.splitList(value.Value); err != nil {
+				return nil, err
+			}
+		}
+		if list.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%s is not a list", describeValue(value))
+		}
+		element := option
+		// Choices and suboptions of a list apply to its elements.
+		element.Type, element.Elements = option.Elements, ""
+		converted = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, e := range list.Content {
+			c, err := im.convert(element, e)
+			if err != nil {
+				return nil, err
+			}
+			if c.Kind != yaml.ScalarNode {
+				converted.Style = 0
+			}
+			converted.Content = append(converted.Content, c)
+		}
+	case "dict":
+		dict := value
+		if text {
+			var root yaml.Node
+			if err := yaml.Unmarshal([]byte(value.Value), &root); err != nil || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode || !strings.HasPrefix(value.Value, "{") {
+				return nil, fmt.Errorf("%q is not a JSON object", value.Value)
+			}
+			dict = root.Content[0]
+		}
+		if dict.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a dict", describeValue(value))
+		}
+		converted = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for i := 0; i+1 < len(dict.Content); i += 2 {
+			key, v := dict.Content[i], dict.Content[i+1]
+			if len(option.Suboptions) > 0 {
+				suboption, ok := option.Suboptions[key.Value]
+				if !ok {
+					return nil, fmt.Errorf("%s is not a suboption, want one of %s", key.Value, strings.Join(atcgModules.OptionNames(option.Suboptions), ", "))
+				}
+				var err error
+				if v, err = im.convert(suboption, v); err != nil {
+					return nil, fmt.Errorf("%s: %w", key.Value, err)
+				}
+			}
+			converted.Content = append(converted.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.Value}, v)
+		}
+		for _, name := range atcgModules.OptionNames(option.Suboptions) {
+			if option.Suboptions[name].Required && !hasKey(converted, name) {
+				return nil, fmt.Errorf("required suboption %s is not set", name)
+			}
+		}
+	case "str", "path":
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("%s is not a string", describeValue(value))
+		}
+		converted = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Value}
+	default:
+		converted = value
+	}
+
+	if len(option.Choices) > 0 && converted.Kind == yaml.ScalarNode {
+		choices := make([]string, len(option.Choices))
+		for i, choice := range option.Choices {
+			choices[i] = fmt.Sprint(choice)
+		}
+		if !contains(choices, converted.Value) {
+			return nil, fmt.Errorf("%q is not one of %s", converted.Value, strings.Join(choices, ", "))
+		}
+	}
+	return converted, nil
+}
+
+// splitList reads a list cell: a JSON list, or values split on the
+// separator.
+func (im *Importer) splitList(cell string) (*yaml.Node, error) {
+	if strings.HasPrefix(cell, "[") {
+		var root yaml.Node
+		if err := yaml.Unmarshal([]byte(cell), &root); err != nil || len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("%q is not a JSON list", cell)
+		}
+		return root.Content[0], nil
+	}
+	separator := im.Separator
+	if separator == "" {
+		separator = ","
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, part := range strings.Split(cell, separator) {
+		if part = strings.TrimSpace(part); part != "" {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part})
+		}
+	}
+	return list, nil
+}
+
+func describeValue(value *yaml.Node) string {
+	switch value.Kind {
+	case yaml.MappingNode:
+		return "a dict"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", value.Value)
+}
@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+func encode(t *testing.T, node *yaml.Node) string {
+	t.Helper()
+	out, err := EncodeVars([]Variable{{Name: "rights", Value: node}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(out)
+}
+
+func TestReadCSV(t *testing.T) {
+	content := "\ufeffRight;User;Note\nSeBackupPrivilege;\"alice, bob\";x\n\n;;\nSeDebugPrivilege;carol\n"
+	table, err := ReadCSV("users.csv", []byte(content), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(table.Columns, "|") != "Right|User|Note" || table.Line != 1 {
+		t.Errorf("unexpected header %q at line %d", table.Columns, table.Line)
+	}
+	if len(table.Rows) != 2 || table.Rows[0].Values[1].Value != "alice, bob" || table.Rows[1].Line != 5 || table.Rows[1].Values[2] != nil {
+		t.Errorf("unexpected rows %+v", table.Rows)
+	}
+
+	if _, err := ReadCSV("empty.csv", nil, 0); err == nil {
+		t.Error("expected an error for a file without a header row")
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	table, err := ReadJSON("users.json", []byte(`[
+  {"name": "SeBackupPrivilege", "users": ["alice"]},
+  {"name": "SeDebugPrivilege", "enabled": true, "users": null}
+]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(table.Columns, "|") != "name|users|enabled" {
+		t.Errorf("unexpected columns %q", table.Columns)
+	}
+	if len(table.Rows) != 2 || table.Rows[1].Line != 3 || table.Rows[1].Values[1] != nil || table.Rows[1].Values[2].Value != "true" {
+		t.Errorf("unexpected rows %+v", table.Rows)
+	}
+
+	if _, err := ReadJSON("users.json", []byte(`{"name": "x"}`)); err == nil {
+		t.Error("expected an error for an object")
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	table, err := ReadCSV("users.csv", []byte(`Name,User,Action,Enabled,Priority,extra,Comment
+SeBackupPrivilege,"alice, bob",add,yes,1,"{""level"": ""2""}",backups
+SeDebugPrivilege,"[""carol""]",,No,,,
+SeTcbPrivilege,{{ admins }},set,,,,
+`), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	importer := &Importer{
+		Module:  "ansible.windows.win_user_right",
+		Doc:     userRightDoc(),
+		Inputs:  atcgTasks.Inputs{Variable: "rights", Loop: atcgTasks.LoopList},
+		Columns: map[string]string{"Comment": ""},
+	}
+	items, problems, err := importer.Import(table)
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("unexpected error: %v %v", err, problems)
+	}
+	want := `---
+rights:
+  # users.csv:2
+  - name: SeBackupPrivilege
+    users: [alice, bob]
+    action: add
+    enabled: true
+    priority: 1
+    extra:
+      level: 2
+  # users.csv:3
+  - name: SeDebugPrivilege
+    users: [carol]
+    enabled: false
+  # users.csv:4
+  - name: SeTcbPrivilege
+    users: '{{ admins }}'
+    action: set
+`
+	if got := encode(t, items); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	importer.Inputs = atcgTasks.Inputs{Variable: "rights", Loop: atcgTasks.LoopDict, KeyOption: "name"}
+	items, _, err = importer.Import(table)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := encode(t, items); !strings.Contains(got, "  # users.csv:3\n  SeDebugPrivilege:\n    users: [carol]\n") {
+		t.Errorf("unexpected dict items\n%s", got)
+	}
+
+	importer.Inputs.Loop = atcgTasks.LoopSingle
+	if _, _, err := importer.Import(table); err == nil {
+		t.Error("expected an error for the single loop strategy")
+	}
+}
+
+func TestImporter_Problems(t *testing.T) {
+	importer := &Importer{Module: "m", Doc: userRightDoc(), Inputs: atcgTasks.Inputs{Variable: "rights", Loop: atcgTasks.LoopList}}
+
+	table, _ := ReadCSV("users.csv", []byte("name,usrs,Users\nx,a,b\n"), 0)
+	_, problems, _ := importer.Import(table)
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	want := []string{
+		`users.csv:1: column "usrs": matches no option of m, did you mean users?`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	table, _ = ReadCSV("users.csv", []byte("user,users\nx,y\n"), 0)
+	if _, problems, _ = importer.Import(table); len(problems) != 1 || !problems[0].Header || !strings.Contains(problems[0].Reason, `holds users, as column "user" does`) {
+		t.Errorf("unexpected problems %v", problems)
+	}
+
+	table, err := ReadCSV("users.csv", []byte("name,action,enabled,priority,extra\nx,append,maybe,one,{\"level\": 1}\n,add,,,\"{\"\"other\"\": 1}\"\n"), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, problems, _ = importer.Import(table)
+	got = nil
+	for _, p := range problems {
+		got = append(got, p.Error())
+	}
+	want = []string{
+		`users.csv:2: column "action": "append" is not one of add, remove, set`,
+		`users.csv:2: column "enabled": "maybe" is not a boolean`,
+		`users.csv:2: column "priority": "one" is not an integer`,
+		`users.csv:3: column "extra": other is not a suboption, want one of level`,
+		`users.csv:3: required option name is not set`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	importer.Inputs = atcgTasks.Inputs{Variable: "rights", Loop: atcgTasks.LoopDict, KeyOption: "name"}
+	importer.Columns = map[string]string{"Name": ""}
+	table, _ = ReadCSV("users.csv", []byte("Name,users\nx,a\ny,b\n"), 0)
+	_, problems, _ = importer.Import(table)
+	if len(problems) != 1 || problems[0].Error() != "users.csv:1: no column holds name, which keys the items with the dict loop" {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
@@ -0,0 +1,48 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	atcgLint "atcg/internal/atcg/lint"
+)
+
+// Formats lists the report formats.
+var Formats = atcgLint.FormatList{"text", "json"}
+
+// WriteReport writes a report in the given format.
+func WriteReport(w io.Writer, format string, report Report) error {
+	format, err := Formats.Parse(format)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		if report.Changes == nil {
+			report.Changes = []Change{}
+		}
+		if report.Ambiguities == nil {
+			report.Ambiguities = []Ambiguity{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	for _, c := range report.Changes {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s -> %s\n", c.File, c.Line, c.Column, c.Old, c.New); err != nil {
+			return err
+		}
+	}
+	for _, a := range report.Ambiguities {
+		line := fmt.Sprintf("%s:%d:%d: %s needs a decision: %s", a.File, a.Line, a.Column, a.Module, a.Reason)
+		if len(a.Candidates) > 0 {
+			line += " (candidates: " + strings.Join(a.Candidates, ", ") + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	report := Report{
+		Changes:     []Change{{File: "site.yml", Line: 5, Column: 7, Old: "copy", New: "ansible.builtin.copy"}},
+		Ambiguities: []Ambiguity{{File: "site.yml", Line: 9, Column: 7, Module: "mystery", Reason: "not found", Candidates: []string{"a.b.mystery", "c.d.mystery"}}},
+	}
+
+	var text bytes.Buffer
+	if err := WriteReport(&text, "", report); err != nil {
+		t.Fatal(err)
+	}
+	want := "site.yml:5:7: copy -> ansible.builtin.copy\nsite.yml:9:7: mystery needs a decision: not found (candidates: a.b.mystery, c.d.mystery)\n"
+	if text.String() != want {
+		t.Errorf("got %q, want %q", text.String(), want)
+	}
+
+	var out bytes.Buffer
+	if err := WriteReport(&out, "json", Report{}); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string][]interface{}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded["changes"] == nil || decoded["ambiguities"] == nil {
+		t.Errorf("unexpected JSON %s: %v", out.String(), err)
+	}
+
+	if err := WriteReport(&out, "sarif", report); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,262 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// VarsMigrator rewrites the option keys of vars files to the names the
+// current documentation of the modules uses.
+type VarsMigrator struct {
+	Modules []atcgLint.VarsModule
+	// Locked maps modules to their documentation from before an upgrade,
+	// which is compared with the current documentation. It may be nil or
+	// lack modules, leaving aliases and deprecations to go by.
+	Locked map[string]*atcgModules.ModuleDoc
+}
+
+// Migrate renames the option keys of loop items, and of flat variables with
+// atcgTasks.LoopSingle: aliases become the option they stand for, and
+// deprecated or removed options the option that replaces them, when the
+// documentation names exactly one. Everything else in the file is kept.
+// Keys that cannot be renamed safely are reported as ambiguities.
+func (m *VarsMigrator) Migrate(file string, content []byte) ([]byte, Report, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, Report{}, err
+	}
+	r := &varsRun{file: file}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return content, Report{}, nil
+	}
+	vars := root.Content[0]
+
+	for i := 0; i+1 < len(vars.Content); i += 2 {
+		key, value := vars.Content[i], vars.Content[i+1]
+		module, option, ok := atcgLint.FindVar(m.Modules, key.Value)
+		if !ok {
+			continue
+		}
+		r.module = module.Module
+		if module.Inputs.Loop != atcgTasks.LoopSingle {
+			r.loop(module, m.locked(module), key.Value, value)
+		} else if option != "" {
+			r.flat(module, m.locked(module), vars, key, option)
+		}
+	}
+
+	out, err := Apply(content, r.edits)
+	if err != nil {
+		return nil, Report{}, err
+	}
+	return out, r.report, nil
+}
+
+// locked returns the locked options of a module, or nil.
+func (m *VarsMigrator) locked(module atcgLint.VarsModule) map[string]atcgModules.ModuleOption {
+	if doc, ok := m.Locked[module.Module]; ok {
+		return doc.Options
+	}
+	for _, doc := range m.Locked {
+		if doc.FQCN() == module.Module {
+			return doc.Options
+		}
+	}
+	return nil
+}
+
+// varsRun collects the edits and report of one vars file.
+type varsRun struct {
+	file   string
+	module string
+	edits  []Edit
+	report Report
+}
+
+func (r *varsRun) flag(node *yaml.Node, format string, args ...interface{}) {
+	r.report.Ambiguities = append(r.report.Ambiguities, Ambiguity{
+		File: r.file, Line: node.Line, Column: node.Column, Module: r.module,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+// rename renames key to name; old and new are the paths reported.
+func (r *varsRun) rename(key *yaml.Node, name, old, new string) {
+	edit, err := NodeEdit(key, key.Value, name)
+	if err != nil {
+		r.flag(key, "%s cannot be renamed to %s in place: %v", old, new, err)
+		return
+	}
+	r.edits = append(r.edits, edit)
+	r.report.Changes = append(r.report.Changes, Change{File: r.file, Line: edit.Line, Column: edit.Column, Old: old, New: new})
+}
+
+// loop migrates the list or dict main.yml loops over.
+func (r *varsRun) loop(module atcgLint.VarsModule, locked map[string]atcgModules.ModuleOption, path string, value *yaml.Node) {
+	switch value.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			r.options(path+"."+value.Content[i].Value, module.Doc.Options, locked, value.Content[i+1], atcgTasks.TaskKey)
+		}
+	case yaml.SequenceNode:
+		for i, item := range value.Content {
+			r.options(path+"["+strconv.Itoa(i)+"]", module.Doc.Options, locked, item, atcgTasks.TaskKey)
+		}
+	}
+}
+
+// options migrates the keys of a mapping of options, recursing into
+// suboptions. The extra key is left as it is.
+func (r *varsRun) options(path string, current, locked map[string]atcgModules.ModuleOption, node *yaml.Node, extra string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		present[node.Content[i].Value] = true
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == extra {
+			continue
+		}
+		name := key.Value
+		target, problem := decide(name, current, locked)
+		switch {
+		case problem != "":
+			r.flag(key, "%s.%s %s", path, name, problem)
+		case target != "" && present[target]:
+			r.flag(key, "%s.%s should be renamed to %s, which is set too", path, name, target)
+		case target != "":
+			r.rename(key, target, path+"."+name, path+"."+target)
+			present[target] = true
+			name = target
+		}
+
+		option, ok := current[name]
+		if !ok || len(option.Suboptions) == 0 {
+			continue
+		}
+		lockedSub := locked[key.Value].Suboptions
+		switch value.Kind {
+		case yaml.MappingNode:
+			r.options(path+"."+name, option.Suboptions, lockedSub, value, "")
+		case yaml.SequenceNode:
+			for j, element := range value.Content {
+				r.options(path+"."+name+"["+strconv.Itoa(j)+"]", option.Suboptions, lockedSub, element, "")
+			}
+		}
+	}
+}
+
+// flat migrates a flat variable of a LoopSingle module.
+func (r *varsRun) flat(module atcgLint.VarsModule, locked map[string]atcgModules.ModuleOption, vars, key *yaml.Node, suffix string) {
+	target, problem := decide(suffix, module.Doc.Options, locked)
+	switch {
+	case problem != "":
+		r.flag(key, "%s %s", key.Value, problem)
+	case target == "":
+	case hasKey(vars, module.Inputs.Flat(target)):
+		r.flag(key, "%s should be renamed to %s, which is set too", key.Value, module.Inputs.Flat(target))
+	default:
+		r.rename(key, module.Inputs.Flat(target), key.Value, module.Inputs.Flat(target))
+	}
+}
+
+func hasKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// decide returns the option a key should be renamed to, "" to keep it, or
+// why a human has to decide.
+func decide(key string, current, locked map[string]atcgModules.ModuleOption) (string, string) {
+	if option, ok := current[key]; ok {
+		if option.Deprecated == nil {
+			return "", ""
+		}
+		if target := replacement(option.Deprecated, current, key); target != "" {
+			return target, ""
+		}
+		return "", "is deprecated: " + option.Deprecated.String()
+	}
+
+	if target := aliasOf(key, current); target != "" {
+		return target, ""
+	}
+	// An alias dropped by the upgrade still names its option.
+	if target := aliasOf(key, locked); target != "" {
+		if _, ok := current[target]; ok {
+			return target, ""
+		}
+	}
+	if option, ok := locked[key]; ok {
+		// A removed option may live on under one of its old aliases.
+		for _, alias := range option.Aliases {
+			if _, ok := current[alias]; ok {
+				return alias, ""
+			}
+			if target := aliasOf(alias, current); target != "" {
+				return target, ""
+			}
+		}
+		if option.Deprecated != nil {
+			if target := replacement(option.Deprecated, current, key); target != "" {
+				return target, ""
+			}
+		}
+		return "", "was removed and needs manual handling"
+	}
+
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if suggestion := atcgLint.Suggest(key, names); suggestion != "" {
+		return "", "is not an option, it may have been removed (did you mean " + suggestion + "?)"
+	}
+	return "", "is not an option, it may have been removed"
+}
+
+// aliasOf returns the option key is an alias of, or "".
+func aliasOf(key string, options map[string]atcgModules.ModuleOption) string {
+	for name, option := range options {
+		if contains(option.Aliases, key) {
+			return name
+		}
+	}
+	return ""
+}
+
+var identifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// replacement returns the one current option, other than key and not
+// deprecated itself, that a deprecation names as its alternative, or "".
+func replacement(deprecation *atcgModules.Deprecation, options map[string]atcgModules.ModuleOption, key string) string {
+	found := ""
+	for _, word := range identifier.FindAllString(deprecation.Alternative, -1) {
+		option, ok := options[word]
+		if !ok || word == key || option.Deprecated != nil || word == found {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = word
+	}
+	return found
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	atcgLint "atcg/internal/atcg/lint"
+	atcgModules "atcg/internal/atcg/modules"
+	atcgTasks "atcg/internal/atcg/tasks"
+)
+
+// userRightDoc is the module the tests of this package migrate and import.
+func userRightDoc() *atcgModules.ModuleDoc {
+	return &atcgModules.ModuleDoc{Collection: "ansible.windows", Module: "win_user_right", Options: map[string]atcgModules.ModuleOption{
+		"name":     {Type: "str", Required: true},
+		"users":    {Type: "list", Elements: "str", Aliases: []string{"user"}},
+		"action":   {Type: "str", Choices: []interface{}{"add", "remove", "set"}},
+		"enabled":  {Type: "bool"},
+		"priority": {Type: "int"},
+		"mode":     {Deprecated: &atcgModules.Deprecation{Why: "Renamed", Alternative: "Use O(action) instead."}},
+		"legacy":   {Deprecated: &atcgModules.Deprecation{Why: "Unused"}},
+		"acl": {Suboptions: map[string]atcgModules.ModuleOption{
+			"rights": {Aliases: []string{"right"}},
+		}},
+		"extra": {Type: "dict", Suboptions: map[string]atcgModules.ModuleOption{"level": {Type: "int"}}},
+	}}
+}
+
+func TestVarsMigrator_Loop(t *testing.T) {
+	content := `---
+# Rights for all hosts
+win_user_right:
+  - name: SeBackupPrivilege  # backups
+    user: [Administrators]
+    mode: add
+    _task: {become: true}
+  - name: SeDenyNetworkLogonRight
+    users: [Guests]
+    user: [Everyone]
+    legacy: true
+    removed_opt: 1
+    acl:
+      - right: read
+other: {user: x}
+`
+	migrator := &VarsMigrator{
+		Modules: []atcgLint.VarsModule{{Module: "ansible.windows.win_user_right", Doc: userRightDoc(), Inputs: atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopList}}},
+		Locked: map[string]*atcgModules.ModuleDoc{"ansible.windows.win_user_right": {Options: map[string]atcgModules.ModuleOption{
+			"removed_opt": {},
+		}}},
+	}
+	got, report, err := migrator.Migrate("group_vars/all.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `---
+# Rights for all hosts
+win_user_right:
+  - name: SeBackupPrivilege  # backups
+    users: [Administrators]
+    action: add
+    _task: {become: true}
+  - name: SeDenyNetworkLogonRight
+    users: [Guests]
+    user: [Everyone]
+    legacy: true
+    removed_opt: 1
+    acl:
+      - rights: read
+other: {user: x}
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	var changes []string
+	for _, c := range report.Changes {
+		changes = append(changes, c.Old+"->"+c.New)
+	}
+	wantChanges := []string{
+		"win_user_right[0].user->win_user_right[0].users",
+		"win_user_right[0].mode->win_user_right[0].action",
+		"win_user_right[1].acl[0].right->win_user_right[1].acl[0].rights",
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("changes = %v, want %v", changes, wantChanges)
+	}
+
+	var reasons []string
+	for _, a := range report.Ambiguities {
+		reasons = append(reasons, a.Reason)
+	}
+	wantReasons := []string{
+		"win_user_right[1].user should be renamed to users, which is set too",
+		"win_user_right[1].legacy is deprecated: Unused",
+		"win_user_right[1].removed_opt was removed and needs manual handling",
+	}
+	if !reflect.DeepEqual(reasons, wantReasons) {
+		t.Errorf("ambiguities = %v, want %v", reasons, wantReasons)
+	}
+}
+
+func TestVarsMigrator_LockedAlias(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"path": {}, "owner": {}}}
+	locked := &atcgModules.ModuleDoc{Collection: "ns.col", Module: "thing", Options: map[string]atcgModules.ModuleOption{
+		"dest":  {Aliases: []string{"path"}},
+		"owner": {Aliases: []string{"user"}},
+	}}
+	migrator := &VarsMigrator{
+		Modules: []atcgLint.VarsModule{{Module: "ns.col.thing", Doc: doc, Inputs: atcgTasks.Inputs{Variable: "thing", Loop: atcgTasks.LoopDict}}},
+		Locked:  map[string]*atcgModules.ModuleDoc{"thing": locked},
+	}
+	got, report, err := migrator.Migrate("vars.yml", []byte("thing:\n  a: {dest: /tmp/a, user: root}\n  b: {dst: /tmp/b}\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != "thing:\n  a: {path: /tmp/a, owner: root}\n  b: {dst: /tmp/b}\n" {
+		t.Errorf("unexpected result %q", got)
+	}
+	if len(report.Ambiguities) != 1 || !strings.Contains(report.Ambiguities[0].Reason, "thing.b.dst is not an option") {
+		t.Errorf("unexpected ambiguities %+v", report.Ambiguities)
+	}
+}
+
+func TestVarsMigrator_Flat(t *testing.T) {
+	migrator := &VarsMigrator{Modules: []atcgLint.VarsModule{{
+		Module: "ansible.windows.win_user_right", Doc: userRightDoc(),
+		Inputs: atcgTasks.Inputs{Variable: "win_user_right", Loop: atcgTasks.LoopSingle},
+	}}}
+	content := "win_user_right_enabled: true\nwin_user_right_name: x\nwin_user_right_user: [a]  # who\nwin_user_right_mode: add\nwin_user_right_action: set\n"
+	got, report, err := migrator.Migrate("vars.yml", []byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "win_user_right_enabled: true\nwin_user_right_name: x\nwin_user_right_users: [a]  # who\nwin_user_right_mode: add\nwin_user_right_action: set\n"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(report.Changes) != 1 || len(report.Ambiguities) != 1 || !strings.Contains(report.Ambiguities[0].Reason, "which is set too") {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,167 @@
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AnsibleInfo describes the Ansible installation reported by ansible-doc --version.
+type AnsibleInfo struct {
+	CoreVersion      string   `json:"core_version"`
+	ConfigFile       string   `json:"config_file,omitempty"`
+	ModuleLocation   string   `json:"module_location,omitempty"`
+	CollectionPaths  []string `json:"collection_paths,omitempty"`
+	Executable       string   `json:"executable,omitempty"`
+	PythonVersion    string   `json:"python_version,omitempty"`
+	PythonExecutable string   `json:"python_executable,omitempty"`
+	JinjaVersion     string   `json:"jinja_version,omitempty"`
+}
+
+// Collection is an installed Ansible collection.
+type Collection struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+var (
+	coreVersionPattern = regexp.MustCompile(`\[core ([^\]]+)\]`)
+	pythonPattern      = regexp.MustCompile(`^(\S+).*\((.+)\)$`)
+)
+
+// GetAnsibleInfo runs ansible-doc --version and parses its output.
+func GetAnsibleInfo(exec CommandExecutor) (*AnsibleInfo, error) {
+	output, err := exec.Execute("ansible-doc", "--version")
+	if err != nil {
+		return nil, fmt.Errorf("error executing ansible-doc: %w", err)
+	}
+
+	info := &AnsibleInfo{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := coreVersionPattern.FindStringSubmatch(line); match != nil && info.CoreVersion == "" {
+			info.CoreVersion = match[1]
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimSpace(line), " = ")
+		if !found {
+			continue
+		}
+		switch key {
+		case "config file":
+			if value != "None" {
+				info.ConfigFile = value
+			}
+		case "ansible python module location":
+			info.ModuleLocation = value
+		case "ansible collection location":
+			info.CollectionPaths = filepath.SplitList(value)
+		case "executable location":
+			info.Executable = value
+		case "python version":
+			if match := pythonPattern.FindStringSubmatch(value); match != nil {
+				info.PythonVersion, info.PythonExecutable = match[1], match[2]
+			} else {
+				info.PythonVersion = value
+			}
+		case "jinja version":
+			info.JinjaVersion = value
+		}
+	}
+
+	if info.CoreVersion == "" {
+		return nil, fmt.Errorf("unrecognised ansible-doc --version output: %q", strings.TrimSpace(string(output)))
+	}
+
+	return info, nil
+}
+
+// ListCollections runs ansible-galaxy and returns the installed collections
+// sorted by name. A collection installed in several paths is listed once per path.
+func ListCollections(exec CommandExecutor) ([]Collection, error) {
+	output, err := exec.Execute("ansible-galaxy", "collection", "list", "--format", "json")
+	if err != nil {
+		return nil, fmt.Errorf("error executing ansible-galaxy: %w", err)
+	}
+
+	var paths map[string]map[string]struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(output, &paths); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	var collections []Collection
+	for path, installed := range paths {
+		for name, details := range installed {
+			collections = append(collections, Collection{Name: name, Version: details.Version, Path: path})
+		}
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i].Name != collections[j].Name {
+			return collections[i].Name < collections[j].Name
+		}
+		return collections[i].Path < collections[j].Path
+	})
+
+	return collections, nil
+}
+
+// Inventory describes what is installed: the ansible-core version, which is
+// also the version of ansible.builtin, and the collections.
+type Inventory struct {
+	CoreVersion string
+	Collections []Collection
+}
+
+// LoadInventory gathers the ansible-core version and the installed collections.
+func LoadInventory(exec CommandExecutor) (*Inventory, error) {
+	info, err := GetAnsibleInfo(exec)
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := ListCollections(exec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Inventory{CoreVersion: info.CoreVersion, Collections: collections}, nil
+}
+
+// CollectionVersion returns the version of the collection doc was read
+// from, or "" if it is not known. When a collection is installed in several
+// paths, the one containing the module file wins.
+func (i *Inventory) CollectionVersion(doc *ModuleDoc) string {
+	if i == nil || doc.Collection == "" {
+		return ""
+	}
+	if doc.Collection == "ansible.builtin" {
+		return i.CoreVersion
+	}
+
+	version := ""
+	for _, collection := range i.Collections {
+		if collection.Name != doc.Collection {
+			continue
+		}
+		namespace, name, _ := strings.Cut(collection.Name, ".")
+		root := filepath.Join(collection.Path, namespace, name) + string(filepath.Separator)
+		if doc.Filename != "" && strings.HasPrefix(doc.Filename, root) {
+			return collection.Version
+		}
+		if version == "" {
+			version = collection.Version
+		}
+	}
+	return version
+}
@@ -0,0 +1,170 @@
+package modules
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ansibleDocVersion = `ansible-doc [core 2.15.3]
+  config file = /etc/ansible/ansible.cfg
+  configured module search path = ['/root/.ansible/plugins/modules']
+  ansible python module location = /usr/lib/python3/dist-packages/ansible
+  ansible collection location = /root/.ansible/collections:/usr/share/ansible/collections
+  executable location = /usr/bin/ansible-doc
+  python version = 3.11.4 (main, Jun  7 2023, 10:13:09) [GCC 12.2.0] (/usr/bin/python3)
+  jinja version = 3.1.2
+  libyaml = True
+`
+
+func TestGetAnsibleInfo_Success(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if command != "ansible-doc" || len(args) != 1 || args[0] != "--version" {
+				t.Errorf("unexpected command: %s %v", command, args)
+			}
+			return []byte(ansibleDocVersion), nil
+		},
+	}
+
+	info, err := GetAnsibleInfo(executor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := &AnsibleInfo{
+		CoreVersion:      "2.15.3",
+		ConfigFile:       "/etc/ansible/ansible.cfg",
+		ModuleLocation:   "/usr/lib/python3/dist-packages/ansible",
+		CollectionPaths:  []string{"/root/.ansible/collections", "/usr/share/ansible/collections"},
+		Executable:       "/usr/bin/ansible-doc",
+		PythonVersion:    "3.11.4",
+		PythonExecutable: "/usr/bin/python3",
+		JinjaVersion:     "3.1.2",
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("unexpected info:\ngot  %+v\nwant %+v", info, expected)
+	}
+}
+
+func TestGetAnsibleInfo_Errors(t *testing.T) {
+	expectedErr := errors.New("command not found")
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return nil, expectedErr
+		},
+	}
+	if _, err := GetAnsibleInfo(executor); !errors.Is(err, expectedErr) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	executor.OutputFunc = func(command string, args ...string) ([]byte, error) {
+		return []byte("something else"), nil
+	}
+	if _, err := GetAnsibleInfo(executor); err == nil || !strings.Contains(err.Error(), "unrecognised") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestListCollections_Success(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if command != "ansible-galaxy" {
+				t.Errorf("unexpected command: %s", command)
+			}
+			return []byte(`{
+				"/usr/share/ansible/collections/ansible_collections": {
+					"community.general": {"version": "7.3.0"},
+					"ansible.windows": {"version": "1.14.0"}
+				},
+				"/root/.ansible/collections/ansible_collections": {
+					"ansible.windows": {"version": "2.0.0"}
+				}
+			}`), nil
+		},
+	}
+
+	collections, err := ListCollections(executor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []Collection{
+		{Name: "ansible.windows", Version: "2.0.0", Path: "/root/.ansible/collections/ansible_collections"},
+		{Name: "ansible.windows", Version: "1.14.0", Path: "/usr/share/ansible/collections/ansible_collections"},
+		{Name: "community.general", Version: "7.3.0", Path: "/usr/share/ansible/collections/ansible_collections"},
+	}
+	if !reflect.DeepEqual(collections, expected) {
+		t.Errorf("unexpected collections:\ngot  %+v\nwant %+v", collections, expected)
+	}
+}
+
+func TestListCollections_InvalidJSON(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return []byte("{invalid-json}"), nil
+		},
+	}
+
+	if _, err := ListCollections(executor); err == nil || !strings.Contains(err.Error(), "unmarshalling") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInventory_CollectionVersion(t *testing.T) {
+	inventory := &Inventory{
+		CoreVersion: "2.15.3",
+		Collections: []Collection{
+			{Name: "ansible.windows", Version: "2.0.0", Path: "/root/.ansible/collections/ansible_collections"},
+			{Name: "ansible.windows", Version: "1.14.0", Path: "/usr/share/ansible/collections/ansible_collections"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		doc      ModuleDoc
+		expected string
+	}{
+		{name: "Builtin", doc: ModuleDoc{Collection: "ansible.builtin"}, expected: "2.15.3"},
+		{
+			name:     "Matched by filename",
+			doc:      ModuleDoc{Collection: "ansible.windows", Filename: "/usr/share/ansible/collections/ansible_collections/ansible/windows/plugins/modules/win_user_right.py"},
+			expected: "1.14.0",
+		},
+		{name: "First installation", doc: ModuleDoc{Collection: "ansible.windows"}, expected: "2.0.0"},
+		{name: "Unknown collection", doc: ModuleDoc{Collection: "community.general"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inventory.CollectionVersion(&tt.doc); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	var missing *Inventory
+	if got := missing.CollectionVersion(&ModuleDoc{Collection: "ansible.builtin"}); got != "" {
+		t.Errorf("expected nil inventory to know nothing, got %q", got)
+	}
+}
+
+func TestLoadInventory(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if command == "ansible-galaxy" {
+				return []byte(`{"/c/ansible_collections": {"ansible.windows": {"version": "2.0.0"}}}`), nil
+			}
+			return []byte(ansibleDocVersion), nil
+		},
+	}
+
+	inventory, err := LoadInventory(executor)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if inventory.CoreVersion != "2.15.3" || len(inventory.Collections) != 1 {
+		t.Errorf("unexpected inventory %+v", inventory)
+	}
+}
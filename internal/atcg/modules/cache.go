@@ -0,0 +1,137 @@
+package modules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CachingExecutor wraps a CommandExecutor and stores the output of
+// successful module documentation lookups on disk, so that repeated runs do
+// not invoke ansible-doc for modules whose documentation was already fetched.
+// Every other command is passed straight through.
+type CachingExecutor struct {
+	// Next is the executor used on a cache miss.
+	Next CommandExecutor
+	// Dir is the cache directory. It is created on first write.
+	Dir string
+	// Salt is mixed into every cache key. Callers set it to the settings
+	// that select the Ansible installation, such as the virtualenv.
+	Salt string
+
+	// installation identifies the Ansible installation Next runs, so that
+	// upgrading ansible-core or a collection, or changing the collection
+	// paths, does not return stale documentation.
+	installation *string
+}
+
+// Execute returns the cached output for the command or runs it through Next.
+func (c *CachingExecutor) Execute(command string, args ...string) ([]byte, error) {
+	if !cacheable(command, args) {
+		return c.Next.Execute(command, args...)
+	}
+
+	installation, ok := c.fingerprint()
+	if !ok {
+		return c.Next.Execute(command, args...)
+	}
+	path := c.path(installation, command, args)
+	if output, err := os.ReadFile(path); err == nil {
+		return output, nil
+	}
+
+	output, err := c.Next.Execute(command, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory %s: %w", c.Dir, err)
+	}
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return nil, fmt.Errorf("error writing cache file %s: %w", path, err)
+	}
+
+	return output, nil
+}
+
+// fingerprint returns the output of ansible-doc --version, which names the
+// ansible-core version and the collection paths, followed by the installed
+// collections and their versions. It is computed once. When either cannot
+// be read, ok is false and nothing is cached, since a key without the
+// collections would outlive a collection upgrade.
+func (c *CachingExecutor) fingerprint() (string, bool) {
+	if c.installation == nil {
+		var installation string
+		version, err := c.Next.Execute("ansible-doc", "--version")
+		if err == nil {
+			var collections []byte
+			if collections, err = c.Next.Execute("ansible-galaxy", "collection", "list", "--format", "json"); err == nil {
+				installation = string(version) + "\x00" + string(collections)
+			}
+		}
+		c.installation = &installation
+	}
+	return *c.installation, *c.installation != ""
+}
+
+// path returns the cache file for a command line run by installation.
+func (c *CachingExecutor) path(installation, command string, args []string) string {
+	sum := sha256.Sum256([]byte(c.Salt + "\x00" + installation + "\x00" + command + "\x00" + strings.Join(args, "\x00")))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cacheable reports whether a command fetches module documentation. Listings
+// and version queries change whenever collections are installed, so they are
+// never cached.
+func cacheable(command string, args []string) bool {
+	if command != "ansible-doc" {
+		return false
+	}
+	json := false
+	for _, arg := range args {
+		switch arg {
+		case "-j", "--json":
+			json = true
+		case "--":
+			// Module names follow.
+			return json
+		case "-l", "--list", "-F", "--list_files", "--version", "--metadata-dump":
+			return false
+		}
+	}
+	return json
+}
+
+// MemoExecutor wraps a CommandExecutor and keeps the output of successful
+// module documentation lookups in memory for the life of the process, so
+// that resolving a module and then rendering it runs ansible-doc once.
+type MemoExecutor struct {
+	Next    CommandExecutor
+	outputs map[string][]byte
+}
+
+// Execute returns the remembered output for the command or runs it through
+// Next.
+func (m *MemoExecutor) Execute(command string, args ...string) ([]byte, error) {
+	if !cacheable(command, args) {
+		return m.Next.Execute(command, args...)
+	}
+
+	key := command + "\x00" + strings.Join(args, "\x00")
+	if output, ok := m.outputs[key]; ok {
+		return output, nil
+	}
+	output, err := m.Next.Execute(command, args...)
+	if err != nil {
+		return nil, err
+	}
+	if m.outputs == nil {
+		m.outputs = make(map[string][]byte)
+	}
+	m.outputs[key] = output
+	return output, nil
+}
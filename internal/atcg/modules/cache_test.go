@@ -0,0 +1,170 @@
+package modules
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCachingExecutor_CachesDocLookups(t *testing.T) {
+	calls := 0
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if args[0] == "-j" {
+				calls++
+			}
+			return []byte(`{"ansible.builtin.ping": {}}`), nil
+		},
+	}
+	executor := &CachingExecutor{Next: next, Dir: t.TempDir()}
+
+	for i := 0; i < 3; i++ {
+		output, err := executor.Execute("ansible-doc", "-j", "ansible.builtin.ping")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if string(output) != `{"ansible.builtin.ping": {}}` {
+			t.Errorf("unexpected output %q", output)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call to the wrapped executor, got %d", calls)
+	}
+}
+
+func TestCachingExecutor_SaltSeparatesEntries(t *testing.T) {
+	dir := t.TempDir()
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return []byte("{}"), nil
+		},
+	}
+
+	first := &CachingExecutor{Next: next, Dir: dir, Salt: "venv-a"}
+	second := &CachingExecutor{Next: next, Dir: dir, Salt: "venv-b"}
+	if first.path("", "ansible-doc", []string{"-j", "ping"}) == second.path("", "ansible-doc", []string{"-j", "ping"}) {
+		t.Error("expected different salts to produce different cache files")
+	}
+}
+
+func TestCachingExecutor_InstallationSeparatesEntries(t *testing.T) {
+	dir := t.TempDir()
+	core, calls := "2.15.3", 0
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			switch {
+			case args[0] == "--version":
+				return []byte("ansible-doc [core " + core + "]"), nil
+			case command == "ansible-galaxy":
+				return []byte(`{"/collections": {"ansible.windows": {"version": "2.0.0"}}}`), nil
+			}
+			calls++
+			return []byte("{}"), nil
+		},
+	}
+
+	(&CachingExecutor{Next: next, Dir: dir}).Execute("ansible-doc", "-j", "ping")
+	(&CachingExecutor{Next: next, Dir: dir}).Execute("ansible-doc", "-j", "ping")
+	if calls != 1 {
+		t.Errorf("expected the second run to hit the cache, got %d calls", calls)
+	}
+	core = "2.16.0"
+	(&CachingExecutor{Next: next, Dir: dir}).Execute("ansible-doc", "-j", "ping")
+	if calls != 2 {
+		t.Errorf("expected an upgraded ansible-core to miss the cache, got %d calls", calls)
+	}
+}
+
+func TestCachingExecutor_BypassedWithoutCollections(t *testing.T) {
+	calls := 0
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			switch {
+			case args[0] == "--version":
+				return []byte("ansible-doc [core 2.15.3]"), nil
+			case command == "ansible-galaxy":
+				return nil, errors.New("unrecognized arguments: --format json")
+			}
+			calls++
+			return []byte("{}"), nil
+		},
+	}
+
+	dir := t.TempDir()
+	(&CachingExecutor{Next: next, Dir: dir}).Execute("ansible-doc", "-j", "ping")
+	(&CachingExecutor{Next: next, Dir: dir}).Execute("ansible-doc", "-j", "ping")
+	if calls != 2 {
+		t.Errorf("expected every lookup to bypass the cache, got %d calls", calls)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected nothing to be cached, got %d entries", len(entries))
+	}
+}
+
+func TestCachingExecutor_PassesThroughOtherCommands(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			calls++
+			return []byte("ansible-doc [core 2.15.3]"), nil
+		},
+	}
+	executor := &CachingExecutor{Next: next, Dir: dir}
+
+	executor.Execute("ansible-doc", "--version")
+	executor.Execute("ansible-doc", "--version")
+	executor.Execute("ansible-doc", "-j", "-l")
+	executor.Execute("ansible-doc", "-j", "-l", "--", "ansible.windows")
+	executor.Execute("ansible-galaxy", "collection", "list", "--format", "json")
+
+	if calls != 5 {
+		t.Errorf("expected every call to reach the wrapped executor, got %d", calls)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected empty cache, got %d entries", len(entries))
+	}
+}
+
+func TestCachingExecutor_DoesNotCacheErrors(t *testing.T) {
+	expectedErr := errors.New("boom")
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return nil, expectedErr
+		},
+	}
+	dir := t.TempDir()
+	executor := &CachingExecutor{Next: next, Dir: dir}
+
+	if _, err := executor.Execute("ansible-doc", "-j", "missing"); !errors.Is(err, expectedErr) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected empty cache, got %d entries", len(entries))
+	}
+}
+
+func TestMemoExecutor(t *testing.T) {
+	calls := map[string]int{}
+	next := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			calls[args[len(args)-1]]++
+			if args[len(args)-1] == "broken" {
+				return nil, errors.New("failed")
+			}
+			return []byte(`{}`), nil
+		},
+	}
+	executor := &MemoExecutor{Next: next}
+
+	for i := 0; i < 2; i++ {
+		executor.Execute("ansible-doc", "-j", "ansible.builtin.ping")
+		executor.Execute("ansible-doc", "-j", "broken")
+		executor.Execute("ansible-doc", "-j", "-l")
+	}
+
+	if calls["ansible.builtin.ping"] != 1 || calls["broken"] != 2 || calls["-l"] != 2 {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
@@ -0,0 +1,73 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Deprecation describes a deprecated module or option.
+type Deprecation struct {
+	Why           string  `json:"why,omitempty"`
+	RemovedIn     Version `json:"removed_in,omitempty"`
+	RemovedAtDate string  `json:"removed_at_date,omitempty"`
+	// Alternative is spelled alternatives in some option docs.
+	Alternative string `json:"alternative,omitempty"`
+
+	// disabled is set for "deprecated: false", which some docs carry.
+	disabled bool
+}
+
+// UnmarshalJSON accepts the deprecation mapping as well as a bare boolean.
+func (d *Deprecation) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		return nil
+	case "false":
+		d.disabled = true
+		return nil
+	}
+
+	type plain Deprecation
+	var raw struct {
+		plain
+		Alternatives string `json:"alternatives"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*d = Deprecation(raw.plain)
+	if d.Alternative == "" {
+		d.Alternative = raw.Alternatives
+	}
+	return nil
+}
+
+// String summarises the deprecation in one line.
+func (d *Deprecation) String() string {
+	var parts []string
+	if d.Why != "" {
+		parts = append(parts, strings.TrimSuffix(d.Why, "."))
+	}
+	switch {
+	case d.RemovedIn != "":
+		parts = append(parts, "removed in "+string(d.RemovedIn))
+	case d.RemovedAtDate != "":
+		parts = append(parts, "removed after "+d.RemovedAtDate)
+	}
+	if alternative := strings.TrimSuffix(d.Alternative, "."); alternative != "" {
+		if !strings.HasPrefix(strings.ToLower(alternative), "use ") {
+			alternative = "use " + alternative
+		}
+		parts = append(parts, alternative)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// active drops a deprecation that was decoded from "deprecated: false".
+func active(d *Deprecation) *Deprecation {
+	if d == nil || d.disabled {
+		return nil
+	}
+	return d
+}
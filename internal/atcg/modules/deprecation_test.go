@@ -0,0 +1,35 @@
+package modules
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeprecation_UnmarshalJSON(t *testing.T) {
+	data := `{
+		"deprecated": {"why": "Replaced.", "removed_in": 3.0, "alternative": "Use M(ns.col.new)."},
+		"options": {
+			"old": {"deprecated": {"why": "Unused", "removed_at_date": "2026-01-01", "alternatives": "new"}},
+			"flag": {"deprecated": false},
+			"legacy": {"deprecated": true}
+		}
+	}`
+
+	var doc ModuleDoc
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if doc.Deprecated == nil || doc.Deprecated.String() != "Replaced; removed in 3.0; Use M(ns.col.new)" {
+		t.Errorf("unexpected module deprecation %+v", doc.Deprecated)
+	}
+	if old := doc.Options["old"].Deprecated; old == nil || old.String() != "Unused; removed after 2026-01-01; use new" {
+		t.Errorf("unexpected option deprecation %+v", old)
+	}
+	if doc.Options["flag"].Deprecated != nil {
+		t.Errorf("expected deprecated: false to be ignored")
+	}
+	if doc.Options["legacy"].Deprecated == nil {
+		t.Errorf("expected deprecated: true to be kept")
+	}
+}
@@ -0,0 +1,61 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Description is documentation text as a list of paragraphs. ansible-doc
+// emits descriptions either as a single string or as a list of strings;
+// both decode into a Description.
+type Description []string
+
+// UnmarshalJSON accepts a string, a list of strings or null.
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+	case string:
+		*d = Description{v}
+	case []interface{}:
+		paragraphs := make(Description, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				paragraphs = append(paragraphs, s)
+			} else {
+				paragraphs = append(paragraphs, fmt.Sprint(item))
+			}
+		}
+		*d = paragraphs
+	default:
+		return fmt.Errorf("description must be a string or a list of strings, got %s", data)
+	}
+	return nil
+}
+
+// Paragraphs returns the non-empty paragraphs with surrounding whitespace
+// removed and runs of whitespace, such as the line breaks of YAML folded
+// scalars, collapsed to single spaces.
+func (d Description) Paragraphs() []string {
+	var paragraphs []string
+	for _, paragraph := range d {
+		if normalized := strings.Join(strings.Fields(paragraph), " "); normalized != "" {
+			paragraphs = append(paragraphs, normalized)
+		}
+	}
+	return paragraphs
+}
+
+// Short returns the first paragraph, or "" when there is none.
+func (d Description) Short() string {
+	if paragraphs := d.Paragraphs(); len(paragraphs) > 0 {
+		return paragraphs[0]
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDescription_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Description
+	}{
+		{name: "String", input: `"The action to take."`, expected: Description{"The action to take."}},
+		{name: "List", input: `["First.", "Second."]`, expected: Description{"First.", "Second."}},
+		{name: "Mixed list", input: `["First.", 2]`, expected: Description{"First.", "2"}},
+		{name: "Null", input: `null`, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Description
+			if err := json.Unmarshal([]byte(tt.input), &d); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(d, tt.expected) {
+				t.Errorf("got %#v, want %#v", d, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDescription_UnmarshalJSON_Invalid(t *testing.T) {
+	var d Description
+	if err := json.Unmarshal([]byte(`{"a": 1}`), &d); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+}
+
+func TestDescription_Paragraphs(t *testing.T) {
+	d := Description{"  Folded\n  text  with   gaps. ", "", "   ", "Second."}
+
+	expected := []string{"Folded text with gaps.", "Second."}
+	if got := d.Paragraphs(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %#v, want %#v", got, expected)
+	}
+	if got := d.Short(); got != "Folded text with gaps." {
+		t.Errorf("unexpected short description %q", got)
+	}
+	if got := (Description{}).Short(); got != "" {
+		t.Errorf("expected empty short description, got %q", got)
+	}
+}
@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// RealExecutor is the default implementation of CommandExecutor.
+//
+// The zero value runs commands from PATH in the current directory with the
+// current environment. The optional fields select a specific ansible-doc
+// binary, a Python virtualenv, extra environment variables and a working
+// directory; they are applied to every command the executor runs so that
+// ansible-doc, ansible-galaxy and ansible all see the same installation.
+type RealExecutor struct {
+	// AnsibleDoc is the path to the ansible-doc binary. Sibling tools such
+	// as ansible-galaxy are looked up in the same directory.
+	AnsibleDoc string
+	// Virtualenv is the root of a Python virtualenv or pipx venv to activate.
+	Virtualenv string
+	// Env holds extra KEY=VALUE environment entries, for example
+	// ANSIBLE_COLLECTIONS_PATH or ANSIBLE_CONFIG.
+	Env []string
+	// Dir is the working directory, used by Ansible for ansible.cfg discovery.
+	Dir string
+}
+
+// Absolute makes AnsibleDoc and Virtualenv absolute, relative to the current
+// directory, so that they keep naming the same files when commands run in
+// Dir. A bare AnsibleDoc name is left to be looked up in PATH.
+func (r *RealExecutor) Absolute() error {
+	if r.AnsibleDoc != "" && filepath.Dir(r.AnsibleDoc) != "." {
+		path, err := filepath.Abs(r.AnsibleDoc)
+		if err != nil {
+			return err
+		}
+		r.AnsibleDoc = path
+	}
+	if r.Virtualenv != "" {
+		path, err := filepath.Abs(r.Virtualenv)
+		if err != nil {
+			return err
+		}
+		r.Virtualenv = path
+	}
+	return nil
+}
+
+// Execute runs the given command and returns its output.
+func (r *RealExecutor) Execute(command string, args ...string) ([]byte, error) {
+	path, err := r.ResolveCommand(command)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = r.Dir
+	cmd.Env = r.Environ()
+	return cmd.Output()
+}
+
+// ResolveCommand returns the executable that Execute would run for command.
+func (r *RealExecutor) ResolveCommand(command string) (string, error) {
+	if r.Virtualenv != "" {
+		if info, err := os.Stat(r.Virtualenv); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("virtualenv %s is not a directory", r.Virtualenv)
+		}
+	}
+
+	if r.AnsibleDoc != "" && strings.HasPrefix(command, "ansible") {
+		if command == "ansible-doc" {
+			return r.AnsibleDoc, nil
+		}
+		if dir := filepath.Dir(r.AnsibleDoc); dir != "." {
+			if sibling := executableIn(dir, command); sibling != "" {
+				return sibling, nil
+			}
+		}
+	}
+
+	if r.Virtualenv != "" {
+		if candidate := executableIn(r.venvBin(), command); candidate != "" {
+			return candidate, nil
+		}
+	}
+
+	return command, nil
+}
+
+// Environ returns the environment commands are run with.
+func (r *RealExecutor) Environ() []string {
+	env := os.Environ()
+
+	if r.Virtualenv != "" {
+		env = setEnv(env, "VIRTUAL_ENV", r.Virtualenv)
+		env = setEnv(env, "PATH", r.venvBin()+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	for _, entry := range r.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		env = setEnv(env, key, value)
+	}
+
+	return env
+}
+
+// venvBin returns the directory holding the virtualenv's executables.
+func (r *RealExecutor) venvBin() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(r.Virtualenv, "Scripts")
+	}
+	return filepath.Join(r.Virtualenv, "bin")
+}
+
+// executableIn returns the path of command in dir, or "" if it is not there.
+func executableIn(dir, command string) string {
+	candidate := filepath.Join(dir, command)
+	if runtime.GOOS == "windows" {
+		candidate += ".exe"
+	}
+	if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+		return candidate
+	}
+	return ""
+}
+
+// setEnv replaces or appends key in a KEY=VALUE environment list.
+func setEnv(env []string, key, value string) []string {
+	prefix := key + "="
+	for i, entry := range env {
+		if strings.HasPrefix(entry, prefix) {
+			env[i] = prefix + value
+			return env
+		}
+	}
+	return append(env, prefix+value)
+}
@@ -0,0 +1,153 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeFakeTool creates an executable shell script named name in dir.
+func writeFakeTool(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestRealExecutor_ResolveCommand_AnsibleDocOverride(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	dir := t.TempDir()
+	doc := writeFakeTool(t, dir, "ansible-doc", "echo doc")
+	galaxy := writeFakeTool(t, dir, "ansible-galaxy", "echo galaxy")
+
+	executor := &RealExecutor{AnsibleDoc: doc}
+
+	if got, _ := executor.ResolveCommand("ansible-doc"); got != doc {
+		t.Errorf("expected %q, got %q", doc, got)
+	}
+	if got, _ := executor.ResolveCommand("ansible-galaxy"); got != galaxy {
+		t.Errorf("expected sibling %q, got %q", galaxy, got)
+	}
+	if got, _ := executor.ResolveCommand("echo"); got != "echo" {
+		t.Errorf("expected non-ansible command to be left alone, got %q", got)
+	}
+}
+
+func TestRealExecutor_Execute_Virtualenv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	venv := t.TempDir()
+	writeFakeTool(t, filepath.Join(venv, "bin"), "ansible-doc", `echo "$VIRTUAL_ENV $ANSIBLE_CONFIG $(pwd)"`)
+	workDir := t.TempDir()
+
+	executor := &RealExecutor{
+		Virtualenv: venv,
+		Env:        []string{"ANSIBLE_CONFIG=/etc/custom.cfg"},
+		Dir:        workDir,
+	}
+
+	output, err := executor.Execute("ansible-doc", "-j", "ping")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := venv + " /etc/custom.cfg " + workDir
+	if strings.TrimSpace(string(output)) != expected {
+		t.Errorf("expected output %q, got %q", expected, string(output))
+	}
+}
+
+func TestRealExecutor_Absolute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	root := t.TempDir()
+	writeFakeTool(t, filepath.Join(root, ".venv", "bin"), "ansible-doc", `echo "$VIRTUAL_ENV"`)
+	workDir := filepath.Join(root, "other")
+	if err := os.MkdirAll(filepath.Join(workDir, ".venv"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+	if root, err = os.Getwd(); err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &RealExecutor{Virtualenv: ".venv", AnsibleDoc: "ansible-doc", Dir: "other"}
+	if err := executor.Absolute(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	executor.AnsibleDoc = ""
+	output, err := executor.Execute("ansible-doc", "--version")
+	if err != nil {
+		t.Fatalf("expected the virtualenv of the current directory to run, got %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != filepath.Join(root, ".venv") {
+		t.Errorf("expected an absolute VIRTUAL_ENV, got %q", got)
+	}
+
+	executor = &RealExecutor{AnsibleDoc: "ansible-doc"}
+	if err := executor.Absolute(); err != nil || executor.AnsibleDoc != "ansible-doc" {
+		t.Errorf("expected a bare name to be kept, got %q, %v", executor.AnsibleDoc, err)
+	}
+	executor = &RealExecutor{AnsibleDoc: filepath.Join(".venv", "bin", "ansible-doc")}
+	if err := executor.Absolute(); err != nil || executor.AnsibleDoc != filepath.Join(root, ".venv", "bin", "ansible-doc") {
+		t.Errorf("expected an absolute ansible-doc, got %q, %v", executor.AnsibleDoc, err)
+	}
+}
+
+func TestRealExecutor_ResolveCommand_MissingVirtualenv(t *testing.T) {
+	executor := &RealExecutor{Virtualenv: filepath.Join(t.TempDir(), "missing")}
+
+	_, err := executor.Execute("ansible-doc", "--version")
+	if err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRealExecutor_Environ(t *testing.T) {
+	t.Setenv("ANSIBLE_COLLECTIONS_PATH", "/old")
+	venv := t.TempDir()
+
+	executor := &RealExecutor{
+		Virtualenv: venv,
+		Env:        []string{"ANSIBLE_COLLECTIONS_PATH=/new"},
+	}
+	env := executor.Environ()
+
+	var collections, path string
+	for _, entry := range env {
+		if value, ok := strings.CutPrefix(entry, "ANSIBLE_COLLECTIONS_PATH="); ok {
+			if collections != "" {
+				t.Errorf("ANSIBLE_COLLECTIONS_PATH set more than once")
+			}
+			collections = value
+		}
+		if value, ok := strings.CutPrefix(entry, "PATH="); ok {
+			path = value
+		}
+	}
+
+	if collections != "/new" {
+		t.Errorf("expected ANSIBLE_COLLECTIONS_PATH=/new, got %q", collections)
+	}
+	if !strings.HasPrefix(path, executor.venvBin()) {
+		t.Errorf("expected PATH to start with %q, got %q", executor.venvBin(), path)
+	}
+}
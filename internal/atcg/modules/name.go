@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// namePart is a Python identifier, which every module name segment is.
+	namePart = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// collectionPart is a Galaxy namespace or collection name.
+	collectionPart = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// nameHelp explains the module name grammar in error messages.
+const nameHelp = "a module name is either a short name such as win_user_right, or a fully qualified collection name such as ansible.windows.win_user_right: a lowercase namespace and collection, then the module, joined by dots; each part starts with a letter and holds only letters, digits and underscores"
+
+// ValidateName checks that name is a short module name or an FQCN. Names are
+// passed to ansible-doc and used to build file names, so anything else, such
+// as a value starting with a dash or holding a path separator, is rejected.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty module name: %s", nameHelp)
+	}
+	parts := strings.Split(name, ".")
+	for _, part := range parts {
+		if !namePart.MatchString(part) {
+			return fmt.Errorf("invalid module name %q: %s", name, nameHelp)
+		}
+	}
+	switch {
+	case len(parts) == 2:
+		return fmt.Errorf("invalid module name %q: %s is a collection, not a module; %s", name, name, nameHelp)
+	case len(parts) > 2:
+		for _, part := range parts[:2] {
+			if !collectionPart.MatchString(part) {
+				return fmt.Errorf("invalid module name %q: namespace and collection %q must be lowercase; %s", name, part, nameHelp)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateCollection checks that name is a collection name such as
+// ansible.windows.
+func ValidateCollection(name string) error {
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 || !collectionPart.MatchString(parts[0]) || !collectionPart.MatchString(parts[1]) {
+		return fmt.Errorf("invalid collection name %q: a collection name is a lowercase namespace and name joined by a dot, such as ansible.windows; each starts with a letter and holds only letters, digits and underscores", name)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"copy", "win_user_right", "ansible.windows.win_user_right", "community.general.system.ufw", "ns2.coll_x.Module1"}
+	for _, name := range valid {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{
+		"", "--help", "-j", "ansible.windows", "../etc/passwd", "a/b", `a\b`,
+		"ansible.windows.", ".copy", "ansible..copy", "Ansible.windows.win_user_right",
+		"ansible.windows.win user", "1copy", "ansible.windows.win-user",
+	}
+	for _, name := range invalid {
+		err := ValidateName(name)
+		if err == nil {
+			t.Errorf("ValidateName(%q) = nil, want an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), "ansible.windows.win_user_right") {
+			t.Errorf("ValidateName(%q) error does not show a valid name: %v", name, err)
+		}
+	}
+}
+
+func TestValidateCollection(t *testing.T) {
+	for _, name := range []string{"ansible.windows", "community.general"} {
+		if err := ValidateCollection(name); err != nil {
+			t.Errorf("ValidateCollection(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "-l", "ansible", "ansible.windows.win_user_right", "../x", "Ansible.windows"} {
+		if err := ValidateCollection(name); err == nil {
+			t.Errorf("ValidateCollection(%q) = nil, want an error", name)
+		}
+	}
+}
+
+func TestParseModuleDoc_RejectsFlags(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			t.Errorf("ansible-doc run with %v", args)
+			return nil, nil
+		},
+	}
+	if _, err := ParseModuleDoc(executor, "--help"); err == nil {
+		t.Fatal("expected an error for --help")
+	}
+	if _, err := ListModules(executor, "--version"); err == nil {
+		t.Fatal("expected an error for --version")
+	}
+}
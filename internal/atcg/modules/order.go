@@ -0,0 +1,119 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// UnmarshalJSON decodes a module doc and records the order of its options.
+// A "deprecated: false" marker leaves Deprecated nil.
+func (d *ModuleDoc) UnmarshalJSON(data []byte) error {
+	type plain ModuleDoc
+	if err := json.Unmarshal(data, (*plain)(d)); err != nil {
+		return err
+	}
+	d.Deprecated = active(d.Deprecated)
+
+	var raw struct {
+		Options json.RawMessage `json:"options"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	order, err := objectKeys(raw.Options)
+	if err != nil {
+		return fmt.Errorf("reading option order: %w", err)
+	}
+	d.OptionOrder = order
+	return nil
+}
+
+// UnmarshalJSON decodes a module option and records the order of its suboptions.
+func (o *ModuleOption) UnmarshalJSON(data []byte) error {
+	type plain ModuleOption
+	if err := json.Unmarshal(data, (*plain)(o)); err != nil {
+		return err
+	}
+	o.Deprecated = active(o.Deprecated)
+
+	var raw struct {
+		Suboptions json.RawMessage `json:"suboptions"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	order, err := objectKeys(raw.Suboptions)
+	if err != nil {
+		return fmt.Errorf("reading suboption order: %w", err)
+	}
+	o.SuboptionOrder = order
+	return nil
+}
+
+// OptionNames returns the option names in documented order. Options missing
+// from OptionOrder, as in docs built by hand, follow in alphabetical order.
+func (d *ModuleDoc) OptionNames() []string {
+	return orderedKeys(d.Options, d.OptionOrder)
+}
+
+// SuboptionNames is OptionNames for suboptions.
+func (o ModuleOption) SuboptionNames() []string {
+	return orderedKeys(o.Suboptions, o.SuboptionOrder)
+}
+
+// orderedKeys returns the keys of m listed in order first, then the rest sorted.
+func orderedKeys[V any](m map[string]V, order []string) []string {
+	keys := make([]string, 0, len(m))
+	seen := make(map[string]bool, len(m))
+	for _, key := range order {
+		if _, ok := m[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+
+	var rest []string
+	for key := range m {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+// objectKeys returns the keys of a JSON object in document order. Empty
+// input and null have no keys.
+func objectKeys(data json.RawMessage) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object, got %v", token)
+	}
+
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,57 @@
+package modules
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestModuleDoc_UnmarshalJSON_KeepsOrder(t *testing.T) {
+	data := `{
+		"short_description": "Manage users",
+		"options": {
+			"name": {"type": "str"},
+			"state": {"type": "str"},
+			"groups": {
+				"type": "list",
+				"suboptions": {"zone": {}, "alpha": {}, "middle": {}}
+			},
+			"account_disabled": {"type": "bool"}
+		}
+	}`
+
+	var doc ModuleDoc
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if doc.ShortDescription != "Manage users" || len(doc.Options) != 4 {
+		t.Errorf("unexpected doc %+v", doc)
+	}
+	if expected := []string{"name", "state", "groups", "account_disabled"}; !reflect.DeepEqual(doc.OptionNames(), expected) {
+		t.Errorf("got option order %v, want %v", doc.OptionNames(), expected)
+	}
+	if expected := []string{"zone", "alpha", "middle"}; !reflect.DeepEqual(doc.Options["groups"].SuboptionNames(), expected) {
+		t.Errorf("got suboption order %v, want %v", doc.Options["groups"].SuboptionNames(), expected)
+	}
+}
+
+func TestModuleDoc_OptionNames_WithoutOrder(t *testing.T) {
+	doc := ModuleDoc{
+		Options:     map[string]ModuleOption{"b": {}, "a": {}, "c": {}},
+		OptionOrder: []string{"c", "missing"},
+	}
+
+	if expected := []string{"c", "a", "b"}; !reflect.DeepEqual(doc.OptionNames(), expected) {
+		t.Errorf("got %v, want %v", doc.OptionNames(), expected)
+	}
+}
+
+func TestObjectKeys(t *testing.T) {
+	if keys, err := objectKeys(json.RawMessage("null")); err != nil || keys != nil {
+		t.Errorf("expected no keys, got %v, %v", keys, err)
+	}
+	if _, err := objectKeys(json.RawMessage("[1]")); err == nil {
+		t.Error("expected an error for a non-object")
+	}
+}
@@ -3,7 +3,8 @@ package modules
 import (
 	"encoding/json"
 	"fmt"
-	"os/exec"
+	"sort"
+	"strings"
 )
 
 // CommandExecutor is an interface for executing commands.
@@ -11,47 +12,201 @@ type CommandExecutor interface {
 	Execute(command string, args ...string) ([]byte, error)
 }
 
-// RealExecutor is the default implementation of CommandExecutor.
-type RealExecutor struct{}
-
-// Execute runs the given command and returns its output.
-func (r *RealExecutor) Execute(command string, args ...string) ([]byte, error) {
-	cmd := exec.Command(command, args...)
-	return cmd.Output()
-}
-
 // ModuleOption represents a module option from ansible-doc output.
 type ModuleOption struct {
-	Choices     []interface{} `json:"choices,omitempty"`
-	Default     interface{}   `json:"default,omitempty"`
-	Description interface{}   `json:"description,omitempty"`
-	Required    bool          `json:"required,omitempty"`
-	Type        string        `json:"type,omitempty"`
+	Aliases      []string                `json:"aliases,omitempty"`
+	Choices      []interface{}           `json:"choices,omitempty"`
+	Default      interface{}             `json:"default,omitempty"`
+	Deprecated   *Deprecation            `json:"deprecated,omitempty"`
+	Description  Description             `json:"description,omitempty"`
+	Elements     string                  `json:"elements,omitempty"`
+	NoLog        bool                    `json:"no_log,omitempty"`
+	Required     bool                    `json:"required,omitempty"`
+	Suboptions   map[string]ModuleOption `json:"suboptions,omitempty"`
+	Type         string                  `json:"type,omitempty"`
+	VersionAdded Version                 `json:"version_added,omitempty"`
+	// SuboptionOrder lists the suboption names in the order ansible-doc
+	// emitted them.
+	SuboptionOrder []string `json:"-"`
+}
+
+// ReturnValue represents a documented return value of a module.
+type ReturnValue struct {
+	Contains    map[string]ReturnValue `json:"contains,omitempty"`
+	Description Description            `json:"description,omitempty"`
+	Elements    string                 `json:"elements,omitempty"`
+	Returned    string                 `json:"returned,omitempty"`
+	Sample      interface{}            `json:"sample,omitempty"`
+	Type        string                 `json:"type,omitempty"`
 }
 
 // ModuleDoc represents the structure of ansible-doc JSON output.
 type ModuleDoc struct {
-	Options map[string]ModuleOption `json:"options"`
+	Module           string                  `json:"module,omitempty"`
+	Collection       string                  `json:"collection,omitempty"`
+	Filename         string                  `json:"filename,omitempty"`
+	ShortDescription string                  `json:"short_description,omitempty"`
+	Description      Description             `json:"description,omitempty"`
+	VersionAdded     Version                 `json:"version_added,omitempty"`
+	Author           interface{}             `json:"author,omitempty"`
+	Requirements     []string                `json:"requirements,omitempty"`
+	Notes            Description             `json:"notes,omitempty"`
+	SeeAlso          []map[string]string     `json:"seealso,omitempty"`
+	Deprecated       *Deprecation            `json:"deprecated,omitempty"`
+	Options          map[string]ModuleOption `json:"options"`
+	// Name is the key ansible-doc reported the documentation under, filled
+	// in by ParseDocs.
+	Name string `json:"-"`
+	// OptionOrder lists the option names in the order ansible-doc emitted
+	// them.
+	OptionOrder []string `json:"-"`
+	// Examples and Return live next to "doc" in ansible-doc output and are
+	// filled in by ParseModuleDoc.
+	Examples string                 `json:"examples,omitempty"`
+	Return   map[string]ReturnValue `json:"return,omitempty"`
+}
+
+// Version is a version string. Older module docs write versions as bare
+// YAML numbers, so numbers are accepted too.
+type Version string
+
+// UnmarshalJSON accepts both JSON strings and numbers.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = Version(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("version must be a string or number, got %s", data)
+	}
+	*v = Version(n.String())
+	return nil
 }
 
 // ParseModuleDoc runs ansible-doc and parses the JSON output for a module.
+// The name is validated first and passed after "--", so that it can never be
+// taken for an ansible-doc flag.
 func ParseModuleDoc(exec CommandExecutor, module string) (*ModuleDoc, error) {
-	output, err := exec.Execute("ansible-doc", "-j", module)
+	if err := ValidateName(module); err != nil {
+		return nil, err
+	}
+	output, err := exec.Execute("ansible-doc", "-j", "--", module)
 	if err != nil {
 		return nil, fmt.Errorf("error executing ansible-doc: %w", err)
 	}
 
-	var docs map[string]struct {
-		Doc ModuleDoc `json:"doc"`
-	}
-	if err := json.Unmarshal(output, &docs); err != nil {
-		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	docs, err := ParseDocs(output)
+	if err != nil {
+		return nil, err
 	}
 
-	docStruct, found := docs[module]
+	doc, found := docs[module]
+	if !found && module != "" && len(docs) == 1 {
+		// ansible-doc keys its output by the name it resolved a short or
+		// redirected name to.
+		for _, only := range docs {
+			doc, found = only, true
+		}
+	}
 	if !found {
 		return nil, fmt.Errorf("module %s not found in ansible-doc output", module)
 	}
+	return doc, nil
+}
+
+// ParseDocs parses the JSON output of ansible-doc -j for any number of
+// modules, keyed by the names ansible-doc reports.
+func ParseDocs(output []byte) (map[string]*ModuleDoc, error) {
+	var raw map[string]struct {
+		Doc      ModuleDoc              `json:"doc"`
+		Examples string                 `json:"examples"`
+		Return   map[string]ReturnValue `json:"return"`
+	}
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	docs := make(map[string]*ModuleDoc, len(raw))
+	for name, entry := range raw {
+		doc := entry.Doc
+		doc.Examples = entry.Examples
+		doc.Return = entry.Return
+		doc.Name = name
+		docs[name] = &doc
+	}
+	return docs, nil
+}
+
+// FQCN returns the fully qualified name of the module, or "" when
+// ansible-doc did not report its collection.
+func (d *ModuleDoc) FQCN() string {
+	if d.Collection == "" || d.Module == "" {
+		return ""
+	}
+	return d.Collection + "." + d.Module
+}
+
+// OptionNames returns the names of options, sorted.
+func OptionNames(options map[string]ModuleOption) []string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Canonical returns the fully qualified name of the module d documents when
+// it was asked for by name: its FQCN, else the name ansible-doc reported if
+// that is qualified, else name qualified by the collection. Without any of
+// these, name is returned as it is.
+func (d *ModuleDoc) Canonical(name string) string {
+	if fqcn := d.FQCN(); fqcn != "" {
+		return fqcn
+	}
+	if strings.Contains(d.Name, ".") {
+		return d.Name
+	}
+	if d.Collection != "" && !strings.Contains(name, ".") {
+		return d.Collection + "." + name
+	}
+	return name
+}
+
+// ModuleSummary is a module name with its short description.
+type ModuleSummary struct {
+	Name             string `json:"name"`
+	ShortDescription string `json:"short_description"`
+}
+
+// ListModules runs ansible-doc --list and returns the available modules,
+// sorted by name. An empty collection lists every installed module.
+func ListModules(exec CommandExecutor, collection string) ([]ModuleSummary, error) {
+	args := []string{"-j", "-l"}
+	if collection != "" {
+		if err := ValidateCollection(collection); err != nil {
+			return nil, err
+		}
+		args = append(args, "--", collection)
+	}
+
+	output, err := exec.Execute("ansible-doc", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing ansible-doc: %w", err)
+	}
+
+	var listing map[string]string
+	if err := json.Unmarshal(output, &listing); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	modules := make([]ModuleSummary, 0, len(listing))
+	for name, description := range listing {
+		modules = append(modules, ModuleSummary{Name: name, ShortDescription: description})
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
 
-	return &docStruct.Doc, nil
+	return modules, nil
 }
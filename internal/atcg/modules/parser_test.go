@@ -46,6 +46,9 @@ func TestParseModuleDoc_Success(t *testing.T) {
 	// Mock CommandExecutor
 	executor := &MockExecutor{
 		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if strings.Join(args, " ") != "-j -- ansible.windows.win_user_right" {
+				t.Errorf("unexpected arguments %v", args)
+			}
 			return []byte(mockOutput), nil
 		},
 	}
@@ -157,6 +160,7 @@ func TestParseModuleDoc_EmptyModuleName(t *testing.T) {
 	}`
 	executor := &MockExecutor{
 		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			t.Errorf("ansible-doc run for an empty module name")
 			return []byte(mockOutput), nil
 		},
 	}
@@ -167,8 +171,135 @@ func TestParseModuleDoc_EmptyModuleName(t *testing.T) {
 		t.Fatal("expected an error, got nil")
 	}
 
-	// Verify that the error is about the module not being found
-	if err.Error() != "module  not found in ansible-doc output" {
+	// Verify that the error is about the module name
+	if !strings.HasPrefix(err.Error(), "empty module name") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestListModules_Success(t *testing.T) {
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if strings.Join(args, " ") != "-j -l -- ansible.windows" {
+				t.Errorf("unexpected arguments %v", args)
+			}
+			return []byte(`{
+				"ansible.windows.win_user_right": "Manage Windows User Rights",
+				"ansible.windows.win_service": "Manage and query Windows services"
+			}`), nil
+		},
+	}
+
+	modules, err := ListModules(executor, "ansible.windows")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(modules) != 2 {
+		t.Fatalf("expected 2 modules, got %d", len(modules))
+	}
+	if modules[0].Name != "ansible.windows.win_service" || modules[1].ShortDescription != "Manage Windows User Rights" {
+		t.Errorf("unexpected modules %+v", modules)
+	}
+}
+
+func TestListModules_Error(t *testing.T) {
+	expectedErr := errors.New("command not found")
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return nil, expectedErr
+		},
+	}
+
+	if _, err := ListModules(executor, ""); !errors.Is(err, expectedErr) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
+
+func TestParseModuleDoc_FullDoc(t *testing.T) {
+	mockOutput := `{
+		"ansible.windows.win_user_right": {
+			"doc": {
+				"collection": "ansible.windows",
+				"short_description": "Manage Windows User Rights",
+				"version_added": 2.4,
+				"options": {
+					"rules": {
+						"type": "list",
+						"elements": "dict",
+						"aliases": ["rule"],
+						"suboptions": {
+							"port": {"type": "int", "version_added": "1.2.0"}
+						}
+					}
+				}
+			},
+			"examples": "- name: Example\n",
+			"return": {
+				"added": {"type": "list", "returned": "success", "sample": ["SYSTEM"]}
+			}
+		}
+	}`
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return []byte(mockOutput), nil
+		},
+	}
+
+	doc, err := ParseModuleDoc(executor, "ansible.windows.win_user_right")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if doc.VersionAdded != "2.4" {
+		t.Errorf("expected numeric version_added to be kept as 2.4, got %q", doc.VersionAdded)
+	}
+	if doc.ShortDescription != "Manage Windows User Rights" || doc.Collection != "ansible.windows" {
+		t.Errorf("unexpected module metadata: %+v", doc)
+	}
+	rules := doc.Options["rules"]
+	if rules.Elements != "dict" || len(rules.Aliases) != 1 || rules.Suboptions["port"].VersionAdded != "1.2.0" {
+		t.Errorf("unexpected rules option: %+v", rules)
+	}
+	if doc.Examples != "- name: Example\n" {
+		t.Errorf("unexpected examples %q", doc.Examples)
+	}
+	if doc.Return["added"].Returned != "success" {
+		t.Errorf("unexpected return values: %+v", doc.Return)
+	}
+}
+
+func TestParseDocs(t *testing.T) {
+	docs, err := ParseDocs([]byte(`{
+		"ansible.windows.win_user_right": {"doc": {"module": "win_user_right", "options": {"users": {"aliases": ["user"]}}}, "examples": "- x"},
+		"ansible.builtin.ping": {"doc": {"module": "ping", "options": {}}}
+	}`))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(docs) != 2 || docs["ansible.windows.win_user_right"].Options["users"].Aliases[0] != "user" || docs["ansible.windows.win_user_right"].Examples != "- x" {
+		t.Errorf("unexpected docs %+v", docs)
+	}
+	if _, err := ParseDocs([]byte("[]")); err == nil {
+		t.Error("expected an error for a JSON list")
+	}
+}
+
+func TestModuleDoc_Canonical(t *testing.T) {
+	tests := []struct {
+		doc  ModuleDoc
+		name string
+		want string
+	}{
+		{doc: ModuleDoc{Module: "win_user", Collection: "ansible.windows"}, name: "win_user", want: "ansible.windows.win_user"},
+		{doc: ModuleDoc{Name: "ansible.windows.win_user"}, name: "win_user", want: "ansible.windows.win_user"},
+		{doc: ModuleDoc{Name: "win_user", Collection: "ansible.windows"}, name: "win_user", want: "ansible.windows.win_user"},
+		{doc: ModuleDoc{Collection: "ansible.windows"}, name: "community.windows.win_user", want: "community.windows.win_user"},
+		{doc: ModuleDoc{}, name: "win_user", want: "win_user"},
+	}
+	for _, tt := range tests {
+		if got := tt.doc.Canonical(tt.name); got != tt.want {
+			t.Errorf("%+v.Canonical(%q) = %q, want %q", tt.doc, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Route is the plugin_routing entry of a module, from ansible-core's
+// ansible_builtin_runtime.yml or a collection's meta/runtime.yml.
+type Route struct {
+	Redirect    string       `yaml:"redirect"`
+	Deprecation *RouteNotice `yaml:"deprecation"`
+	Tombstone   *RouteNotice `yaml:"tombstone"`
+}
+
+// RouteNotice describes a deprecation or removal in plugin routing.
+type RouteNotice struct {
+	RemovalVersion string `yaml:"removal_version"`
+	RemovalDate    string `yaml:"removal_date"`
+	WarningText    string `yaml:"warning_text"`
+}
+
+// String joins the warning text with the removal version or date.
+func (n *RouteNotice) String() string {
+	var parts []string
+	if n.WarningText != "" {
+		parts = append(parts, strings.TrimSpace(n.WarningText))
+	}
+	if n.RemovalVersion != "" {
+		parts = append(parts, "removal in version "+n.RemovalVersion)
+	}
+	if n.RemovalDate != "" {
+		parts = append(parts, "removal after "+n.RemovalDate)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Routing maps module FQCNs to their routes. Routes of ansible-core are
+// keyed below ansible.builtin.
+type Routing map[string]Route
+
+// AddRuntime adds the module routes of a runtime.yml belonging to
+// collection.
+func (r Routing) AddRuntime(collection string, content []byte) error {
+	var runtime struct {
+		PluginRouting struct {
+			Modules map[string]Route `yaml:"modules"`
+		} `yaml:"plugin_routing"`
+	}
+	if err := yaml.Unmarshal(content, &runtime); err != nil {
+		return err
+	}
+	for name, route := range runtime.PluginRouting.Modules {
+		r[collection+"."+name] = route
+	}
+	return nil
+}
+
+// LoadRouting reads the module routing of ansible-core and of every
+// installed collection. Whatever could be read is returned along with the
+// first error.
+func LoadRouting(exec CommandExecutor) (Routing, error) {
+	routing := make(Routing)
+
+	info, err := GetAnsibleInfo(exec)
+	if err != nil {
+		return routing, err
+	}
+	if info.ModuleLocation != "" {
+		path := filepath.Join(info.ModuleLocation, "config", "ansible_builtin_runtime.yml")
+		if content, err := os.ReadFile(path); err == nil {
+			if err := routing.AddRuntime("ansible.builtin", content); err != nil {
+				return routing, fmt.Errorf("parsing %s: %w", path, err)
+			}
+		}
+	}
+
+	collections, err := ListCollections(exec)
+	if err != nil {
+		return routing, err
+	}
+	for _, collection := range collections {
+		namespace, name, _ := strings.Cut(collection.Name, ".")
+		path := filepath.Join(collection.Path, namespace, name, "meta", "runtime.yml")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if err := routing.AddRuntime(collection.Name, content); err != nil {
+			return routing, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	return routing, nil
+}
+
+// LazyRouting returns a function loading the module routing with
+// LoadRouting the first time it is called. Without routing data, redirects
+// are left to ansible-doc, so errors give an empty routing.
+func LazyRouting(exec CommandExecutor) func() Routing {
+	var routing Routing
+	return func() Routing {
+		if routing == nil {
+			routing, _ = LoadRouting(exec)
+			if routing == nil {
+				routing = make(Routing)
+			}
+		}
+		return routing
+	}
+}
+
+// RemovedError reports a module whose routing holds a tombstone.
+type RemovedError struct {
+	Module string
+	Notice *RouteNotice
+}
+
+func (e *RemovedError) Error() string {
+	return fmt.Sprintf("module %s has been removed: %s", e.Module, e.Notice)
+}
+
+// maxRedirects bounds redirect chains, which Ansible also refuses to follow
+// in a loop.
+const maxRedirects = 10
+
+// Follow returns the FQCN a module name routes to, following redirects, and
+// warnings for every redirect and deprecation on the way. A short name is
+// looked up below ansible.builtin and returned as it is when no route
+// applies. A tombstone is an error.
+func (r Routing) Follow(name string) (string, []string, error) {
+	key := name
+	if !strings.Contains(name, ".") {
+		key = "ansible.builtin." + name
+	}
+
+	var warnings []string
+	seen := map[string]bool{}
+	for i := 0; ; i++ {
+		route, ok := r[key]
+		if !ok {
+			break
+		}
+		if route.Tombstone != nil {
+			return "", warnings, &RemovedError{Module: key, Notice: route.Tombstone}
+		}
+		if route.Deprecation != nil {
+			warnings = append(warnings, fmt.Sprintf("module %s is deprecated: %s", key, route.Deprecation))
+		}
+		if route.Redirect == "" {
+			break
+		}
+		if seen[key] || i >= maxRedirects {
+			return "", warnings, fmt.Errorf("module %s: redirect loop", name)
+		}
+		seen[key] = true
+		warnings = append(warnings, fmt.Sprintf("module %s is redirected to %s", key, route.Redirect))
+		key = route.Redirect
+	}
+
+	if key == "ansible.builtin."+name {
+		return name, warnings, nil
+	}
+	return key, warnings, nil
+}
+
+// Resolution is a module name resolved to its canonical FQCN.
+type Resolution struct {
+	Name     string
+	Doc      *ModuleDoc
+	Warnings []string
+}
+
+// Resolver resolves module names as Ansible does: through the collections
+// search list, plugin routing, and finally ansible-doc.
+type Resolver struct {
+	Exec    CommandExecutor
+	Routing Routing
+	// LoadRouting, when set and Routing is nil, loads the routing the first
+	// time a name needs it: a short name, or a qualified name ansible-doc
+	// redirects or cannot find.
+	LoadRouting func() Routing
+	// Docs returns the documentation of a module. It defaults to
+	// ParseModuleDoc with Exec.
+	Docs func(module string) (*ModuleDoc, error)
+	// Collections is searched for short names before ansible.builtin.
+	Collections []string
+}
+
+// Resolve returns the canonical FQCN and documentation of a module.
+func (r *Resolver) Resolve(name string) (*Resolution, error) {
+	short := !strings.Contains(name, ".")
+	candidates := []string{name}
+	if short {
+		candidates = nil
+		for _, collection := range r.Collections {
+			candidates = append(candidates, collection+"."+name)
+		}
+		candidates = append(candidates, name)
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		target := candidate
+		var warnings []string
+		if short {
+			var err error
+			if target, warnings, err = r.routing().Follow(candidate); err != nil {
+				return nil, err
+			}
+		}
+		doc, err := r.doc(target)
+		if !short && (err != nil || doc.Canonical(target) != target) {
+			// The routing tells where a qualified name went, and why.
+			followed, routed, routeErr := r.routing().Follow(candidate)
+			if routeErr != nil {
+				return nil, routeErr
+			}
+			warnings = routed
+			if followed != target {
+				target = followed
+				doc, err = r.doc(target)
+			}
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		canonical := doc.Canonical(target)
+		if canonical != target && !strings.HasSuffix(canonical, "."+target) {
+			warnings = append(warnings, fmt.Sprintf("module %s is redirected to %s", target, canonical))
+		}
+		if doc.Deprecated != nil {
+			warnings = append(warnings, fmt.Sprintf("module %s is deprecated: %s", canonical, doc.Deprecated))
+		}
+		return &Resolution{Name: canonical, Doc: doc, Warnings: warnings}, nil
+	}
+	return nil, lastErr
+}
+
+// routing returns the routing, loading it on first use.
+func (r *Resolver) routing() Routing {
+	if r.Routing == nil && r.LoadRouting != nil {
+		r.Routing = r.LoadRouting()
+	}
+	return r.Routing
+}
+
+// doc returns the documentation of a module.
+func (r *Resolver) doc(module string) (*ModuleDoc, error) {
+	if r.Docs != nil {
+		return r.Docs(module)
+	}
+	return ParseModuleDoc(r.Exec, module)
+}
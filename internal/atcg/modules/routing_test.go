@@ -0,0 +1,182 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const builtinRuntime = `
+plugin_routing:
+  modules:
+    win_user:
+      redirect: ansible.windows.win_user
+    win_msi:
+      redirect: ansible.windows.win_package
+      deprecation:
+        removal_version: "3.0.0"
+        warning_text: Use win_package instead.
+    old_thing:
+      tombstone:
+        removal_version: "2.10"
+        warning_text: Gone for good.
+    loop_a:
+      redirect: ansible.builtin.loop_b
+    loop_b:
+      redirect: ansible.builtin.loop_a
+`
+
+func testRouting(t *testing.T) Routing {
+	t.Helper()
+	routing := make(Routing)
+	if err := routing.AddRuntime("ansible.builtin", []byte(builtinRuntime)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return routing
+}
+
+func TestRouting_Follow(t *testing.T) {
+	routing := testRouting(t)
+
+	tests := []struct {
+		name     string
+		want     string
+		warnings int
+		err      string
+	}{
+		{name: "copy", want: "copy"},
+		{name: "ansible.builtin.copy", want: "ansible.builtin.copy"},
+		{name: "win_user", want: "ansible.windows.win_user", warnings: 1},
+		{name: "ansible.builtin.win_msi", want: "ansible.windows.win_package", warnings: 2},
+		{name: "old_thing", err: "module ansible.builtin.old_thing has been removed: Gone for good.; removal in version 2.10"},
+		{name: "loop_a", err: "redirect loop"},
+	}
+	for _, tt := range tests {
+		got, warnings, err := routing.Follow(tt.name)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Follow(%q): expected error containing %q, got %v", tt.name, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want || len(warnings) != tt.warnings {
+			t.Errorf("Follow(%q) = %q, %v, %v; want %q with %d warnings", tt.name, got, warnings, err, tt.want, tt.warnings)
+		}
+	}
+}
+
+func TestLoadRouting(t *testing.T) {
+	core := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(core, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(core, "config", "ansible_builtin_runtime.yml"), []byte(builtinRuntime), 0644); err != nil {
+		t.Fatal(err)
+	}
+	collections := t.TempDir()
+	meta := filepath.Join(collections, "community", "general", "meta")
+	if err := os.MkdirAll(meta, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(meta, "runtime.yml"), []byte("plugin_routing:\n  modules:\n    foo:\n      redirect: community.general.bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			if command == "ansible-doc" {
+				return []byte("ansible-doc [core 2.15.3]\n  ansible python module location = " + core + "\n"), nil
+			}
+			return []byte(`{"` + collections + `": {"community.general": {"version": "8.0.0"}}}`), nil
+		},
+	}
+	routing, err := LoadRouting(executor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if routing["ansible.builtin.win_user"].Redirect != "ansible.windows.win_user" || routing["community.general.foo"].Redirect != "community.general.bar" {
+		t.Errorf("unexpected routing %+v", routing)
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	docs := map[string]string{
+		"copy":                            `{"ansible.builtin.copy": {"doc": {"module": "copy", "collection": "ansible.builtin"}}}`,
+		"ansible.windows.win_user":        `{"ansible.windows.win_user": {"doc": {"module": "win_user", "collection": "ansible.windows"}}}`,
+		"community.windows.win_user":      `{}`,
+		"community.general.copy":          `{}`,
+		"ansible.windows.win_package":     `{"ansible.windows.win_package": {"doc": {"module": "win_package", "collection": "ansible.windows", "deprecated": {"why": "old"}}}}`,
+		"ansible.builtin.include_vars":    `{"ansible.builtin.include_vars": {"doc": {}}}`,
+		"community.general.win_shortcut2": `{"community.windows.win_shortcut": {"doc": {"module": "win_shortcut", "collection": "community.windows"}}}`,
+	}
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return []byte(docs[args[len(args)-1]]), nil
+		},
+	}
+	resolver := &Resolver{Exec: executor, Routing: testRouting(t), Collections: []string{"community.general"}}
+
+	tests := []struct {
+		name     string
+		want     string
+		warnings []string
+	}{
+		{name: "copy", want: "ansible.builtin.copy"},
+		{name: "win_user", want: "ansible.windows.win_user", warnings: []string{"module ansible.builtin.win_user is redirected to ansible.windows.win_user"}},
+		{name: "win_msi", want: "ansible.windows.win_package", warnings: []string{
+			"module ansible.builtin.win_msi is deprecated: Use win_package instead.; removal in version 3.0.0",
+			"module ansible.builtin.win_msi is redirected to ansible.windows.win_package",
+			"module ansible.windows.win_package is deprecated: old",
+		}},
+		{name: "ansible.builtin.include_vars", want: "ansible.builtin.include_vars"},
+		{name: "community.general.win_shortcut2", want: "community.windows.win_shortcut", warnings: []string{"module community.general.win_shortcut2 is redirected to community.windows.win_shortcut"}},
+	}
+	for _, tt := range tests {
+		got, err := resolver.Resolve(tt.name)
+		if err != nil {
+			t.Errorf("Resolve(%q): unexpected error %v", tt.name, err)
+			continue
+		}
+		if got.Name != tt.want || !reflect.DeepEqual(got.Warnings, tt.warnings) {
+			t.Errorf("Resolve(%q) = %q %q, want %q %q", tt.name, got.Name, got.Warnings, tt.want, tt.warnings)
+		}
+	}
+
+	if _, err := resolver.Resolve("old_thing"); err == nil || !strings.Contains(err.Error(), "has been removed") {
+		t.Errorf("expected a tombstone error, got %v", err)
+	}
+	if _, err := resolver.Resolve("nope"); err == nil {
+		t.Error("expected an error for an unknown module")
+	}
+}
+
+func TestResolver_LazyRouting(t *testing.T) {
+	docs := map[string]string{
+		"ansible.windows.win_user":        `{"ansible.windows.win_user": {"doc": {"module": "win_user", "collection": "ansible.windows"}}}`,
+		"win_user":                        `{"ansible.windows.win_user": {"doc": {}}}`,
+		"community.general.win_shortcut2": `{"community.windows.win_shortcut": {"doc": {"module": "win_shortcut", "collection": "community.windows"}}}`,
+	}
+	executor := &MockExecutor{
+		OutputFunc: func(command string, args ...string) ([]byte, error) {
+			return []byte(docs[args[len(args)-1]]), nil
+		},
+	}
+	loads := 0
+	resolver := &Resolver{Exec: executor, LoadRouting: func() Routing {
+		loads++
+		return make(Routing)
+	}}
+
+	if got, err := resolver.Resolve("ansible.windows.win_user"); err != nil || got.Name != "ansible.windows.win_user" || loads != 0 {
+		t.Errorf("Resolve(FQCN) = %+v, %v with %d routing loads, want no load", got, err, loads)
+	}
+	// ansible-doc reports the short name under its FQCN, but no collection.
+	if got, err := resolver.Resolve("win_user"); err != nil || got.Name != "ansible.windows.win_user" || loads != 1 {
+		t.Errorf("Resolve(short) = %+v, %v with %d routing loads, want 1", got, err, loads)
+	}
+	if got, err := resolver.Resolve("community.general.win_shortcut2"); err != nil || got.Name != "community.windows.win_shortcut" || loads != 1 {
+		t.Errorf("Resolve(redirected) = %+v, %v with %d routing loads, want 1", got, err, loads)
+	}
+}
@@ -0,0 +1,89 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	atcgMarkup "atcg/internal/atcg/markup"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// commentWidth is the column generated comments are wrapped at.
+const commentWidth = 80
+
+// moduleHeader renders the comment block at the top of a task file.
+func moduleHeader(module string, doc *atcgModules.ModuleDoc, inventory *atcgModules.Inventory) string {
+	var b strings.Builder
+
+	title := module
+	if doc.ShortDescription != "" {
+		title += " - " + doc.ShortDescription
+	}
+	b.WriteString(atcgMarkup.YAMLComment(atcgMarkup.ParseAll([]string{title}), "", commentWidth))
+
+	if doc.Collection != "" {
+		source := doc.Collection
+		if version := inventory.CollectionVersion(doc); version != "" {
+			source += " " + version
+		}
+		b.WriteString("# Source: " + source + "\n")
+	}
+
+	return b.String()
+}
+
+// optionComment renders the comment placed above an option: its short
+// description followed by a line summarising its type and constraints.
+func optionComment(option atcgModules.ModuleOption, indent string) string {
+	var b strings.Builder
+
+	if short := option.Description.Short(); short != "" {
+		b.WriteString(atcgMarkup.YAMLComment(atcgMarkup.ParseAll([]string{short}), indent, commentWidth))
+	}
+
+	var facts []string
+	if option.Type != "" {
+		typ := option.Type
+		if option.Elements != "" {
+			typ += " of " + option.Elements
+		}
+		facts = append(facts, "type: "+typ)
+	}
+	if option.Required {
+		facts = append(facts, "required")
+	}
+	if len(option.Choices) > 0 {
+		choices := make([]string, len(option.Choices))
+		for i, choice := range option.Choices {
+			choices[i] = commentValue(choice)
+		}
+		facts = append(facts, "choices: "+strings.Join(choices, ", "))
+	}
+	if option.Default != nil {
+		facts = append(facts, "default: "+commentValue(option.Default))
+	}
+	if len(option.Aliases) > 0 {
+		facts = append(facts, "aliases: "+strings.Join(option.Aliases, ", "))
+	}
+	if option.VersionAdded != "" {
+		facts = append(facts, "added in: "+string(option.VersionAdded))
+	}
+	if len(facts) > 0 {
+		b.WriteString(indent + "# " + strings.Join(facts, " | ") + "\n")
+	}
+
+	return b.String()
+}
+
+// commentValue renders a documented value on a single line.
+func commentValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
@@ -0,0 +1,46 @@
+package tasks
+
+import (
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestOptionComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		option   atcgModules.ModuleOption
+		expected string
+	}{
+		{
+			name:     "Bare option",
+			option:   atcgModules.ModuleOption{},
+			expected: "",
+		},
+		{
+			name:     "List with structured default",
+			option:   atcgModules.ModuleOption{Type: "list", Elements: "int", Default: []interface{}{80.0, 443.0}},
+			expected: "  # type: list of int | default: [80,443]\n",
+		},
+		{
+			name:     "Description only",
+			option:   atcgModules.ModuleOption{Description: atcgModules.Description{"Whether to I(force) it."}},
+			expected: "  # Whether to force it.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionComment(tt.option, "  "); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestModuleHeader_WithoutMetadata(t *testing.T) {
+	expected := "# ansible.builtin.debug\n"
+	if got := moduleHeader("ansible.builtin.debug", &atcgModules.ModuleDoc{}, nil); got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
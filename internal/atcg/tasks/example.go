@@ -0,0 +1,181 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// RenderExample renders sample variables for the task file of module, in
+// the shape main.yml loops over: one item setting every option to its
+// default, its first choice or a placeholder for its type, with a comment
+// saying whether it is required. Suboptions are written as nested items.
+// With minimal, only required options are written. Deprecated options are
+// left out. The result has no document start, so that the examples of
+// several modules can be joined into one vars file.
+func RenderExample(module string, doc *atcgModules.ModuleDoc, opts Options, minimal bool) (string, error) {
+	fields, err := orderFields(doc, opts)
+	if err != nil {
+		return "", err
+	}
+	vars := newItemVars(opts.Name(module), opts)
+	key := ""
+	if vars.loop == LoopDict {
+		key = keyOption(doc, opts.Label, opts.SensitivePatterns)
+	}
+
+	comment := fmt.Sprintf("Example item for %s, generated by atcg.\nReplace the values in angle brackets.", module)
+	if minimal {
+		comment = fmt.Sprintf("Required options of %s, generated by atcg.\nReplace the values in angle brackets.", module)
+	}
+
+	e := example{minimal: minimal, order: opts.Order}
+	item := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, f := range fields {
+		if f.Name != key {
+			e.add(item, f.Name, f.Option)
+		}
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	switch vars.loop {
+	case LoopSingle:
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			item.Content[i].Value = vars.ref(item.Content[i].Value)
+		}
+		// main.yml only includes the task file when it is enabled.
+		enabled := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: vars.ref("enabled"), HeadComment: comment, LineComment: "required | type: bool | includes the task file"}
+		root.Content = append([]*yaml.Node{enabled, {Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"}}, item.Content...)
+	case LoopDict:
+		dictKey := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "<key>"}
+		if len(item.Content) == 0 {
+			item.Style = yaml.FlowStyle
+		}
+		if key != "" {
+			dictKey.Value = "<" + key + ">"
+			setComment(dictKey, item, "fills "+key+" | "+facts(doc.Options[key], false))
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: vars.basename, HeadComment: comment},
+			&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{dictKey, item}})
+	default:
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: vars.basename, HeadComment: comment},
+			&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}})
+	}
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return "", fmt.Errorf("encoding example for %s: %w", module, err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// example renders the sample values of options.
+type example struct {
+	minimal bool
+	order   Order
+}
+
+// add appends the key and sample value of an option to mapping, unless it
+// is deprecated or left out by minimal.
+func (e example) add(mapping *yaml.Node, name string, option atcgModules.ModuleOption) {
+	if option.Deprecated != nil || (e.minimal && !option.Required) {
+		return
+	}
+	value, isDefault := e.value(option)
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	setComment(key, value, facts(option, isDefault))
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+// value returns the sample value of an option and whether it is the
+// documented default.
+func (e example) value(option atcgModules.ModuleOption) (*yaml.Node, bool) {
+	if option.Default != nil {
+		var node yaml.Node
+		if err := node.Encode(option.Default); err == nil {
+			return &node, true
+		}
+	}
+	if len(option.Choices) > 0 && option.Type != "list" {
+		var node yaml.Node
+		if err := node.Encode(option.Choices[0]); err == nil {
+			return &node, false
+		}
+	}
+
+	switch option.Type {
+	case "bool":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}, false
+	case "int":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"}, false
+	case "float":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: "0.0"}, false
+	case "list":
+		element := option
+		element.Type, element.Elements, element.Default = option.Elements, "", nil
+		value, _ := e.value(element)
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}}, false
+	case "dict":
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		names := atcgModules.OptionNames(option.Suboptions)
+		if e.order == OrderDocumented {
+			names = option.SuboptionNames()
+		}
+		for _, name := range names {
+			e.add(mapping, name, option.Suboptions[name])
+		}
+		if len(mapping.Content) == 0 {
+			mapping.Style = yaml.FlowStyle
+		}
+		return mapping, false
+	case "path":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "<path>"}, false
+	case "str":
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "<str>"}, false
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "<value>"}, false
+}
+
+// setComment sets the comment beside a key, which goes after the value when
+// that is written on the same line.
+func setComment(key, value *yaml.Node, text string) {
+	if value.Style == yaml.FlowStyle {
+		value.LineComment = text
+		return
+	}
+	key.LineComment = text
+}
+
+// facts summarises an option in the comment beside its sample value.
+func facts(option atcgModules.ModuleOption, isDefault bool) string {
+	parts := []string{"optional"}
+	if option.Required {
+		parts[0] = "required"
+	}
+	if option.Type != "" {
+		typ := option.Type
+		if option.Elements != "" {
+			typ += " of " + option.Elements
+		}
+		parts = append(parts, "type: "+typ)
+	}
+	if len(option.Choices) > 0 {
+		choices := make([]string, len(option.Choices))
+		for i, choice := range option.Choices {
+			choices[i] = commentValue(choice)
+		}
+		parts = append(parts, "choices: "+strings.Join(choices, ", "))
+	}
+	if isDefault {
+		parts = append(parts, "default")
+	}
+	return strings.Join(parts, " | ")
+}
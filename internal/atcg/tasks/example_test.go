@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestRenderExample(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":   {Type: "str", Required: true},
+		"users":  {Type: "list", Elements: "str"},
+		"action": {Type: "str", Choices: []interface{}{"add", "remove", "set"}, Default: "set"},
+		"force":  {Type: "bool"},
+		"mode":   {Type: "str", Deprecated: &atcgModules.Deprecation{Why: "Renamed"}},
+		"acl": {Type: "list", Elements: "dict", Suboptions: map[string]atcgModules.ModuleOption{
+			"rights":  {Type: "str", Required: true},
+			"inherit": {Type: "bool", Default: true},
+		}},
+		"extra": {Type: "dict"},
+	}}
+	got, err := RenderExample("ansible.windows.win_user_right", doc, Options{}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# Example item for ansible.windows.win_user_right, generated by atcg.
+# Replace the values in angle brackets.
+win_user_right:
+  - acl: # optional | type: list of dict
+      - inherit: true # optional | type: bool | default
+        rights: <str> # required | type: str
+    action: set # optional | type: str | choices: add, remove, set | default
+    extra: {} # optional | type: dict
+    force: false # optional | type: bool
+    name: <str> # required | type: str
+    users: # optional | type: list of str
+      - <str>
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	var parsed map[string][]map[string]interface{}
+	if err := yaml.Unmarshal([]byte(got), &parsed); err != nil || len(parsed["win_user_right"]) != 1 {
+		t.Errorf("example does not parse as a list of items: %v", err)
+	}
+}
+
+func TestRenderExample_Minimal(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Type: "str", Required: true},
+		"users": {Type: "list", Elements: "str"},
+	}}
+	got, err := RenderExample("ansible.windows.win_user_right", doc, Options{Loop: LoopDict}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# Required options of ansible.windows.win_user_right, generated by atcg.
+# Replace the values in angle brackets.
+win_user_right:
+  <name>: {} # fills name | required | type: str
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = RenderExample("ansible.windows.win_user_right", doc, Options{Loop: LoopSingle, Prefix: "site_"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `# Required options of ansible.windows.win_user_right, generated by atcg.
+# Replace the values in angle brackets.
+site_win_user_right_enabled: true # required | type: bool | includes the task file
+site_win_user_right_name: <str> # required | type: str
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -3,7 +3,6 @@ package tasks
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"text/template"
 
@@ -12,40 +11,153 @@ import (
 
 // Module represents details for a single module.
 type Module struct {
-	Name     string
+	Name string
+	// Basename names the task file, the variables and the tags of the
+	// module. It follows Options.Naming.
 	Basename string
+	// Label is the loop_control label main.yml uses for the module's
+	// items. It is empty unless items may hold secrets.
+	Label string
+	// Path is the task file relative to the output directory. Empty means
+	// Basename.yml.
+	Path string
 }
 
+// Options controls how task files are rendered. The zero value renders
+// bare task files.
+type Options struct {
+	// Comments adds a documentation comment above every option and a
+	// header describing the module.
+	Comments bool
+	// Inventory supplies the collection version shown in the header. It
+	// may be nil.
+	Inventory *atcgModules.Inventory
+	// Order selects the option order. The empty value is OrderAlphabetical.
+	Order Order
+	// Groups defines the sections used by OrderGrouped.
+	Groups []Group
+	// Guards validates loop items before the module runs: required options
+	// go through the mandatory filter and an assert task checks keys, types
+	// and choices. main.yml then passes the loop index for error messages.
+	Guards bool
+	// NoLog sets no_log on tasks with sensitive options and keeps their
+	// items out of the include labels in main.yml.
+	NoLog bool
+	// SensitivePatterns are the option name patterns treated as secrets.
+	// Empty means DefaultSensitivePatterns.
+	SensitivePatterns []string
+	// Loop selects how main.yml passes values. The empty value is LoopList.
+	Loop Loop
+	// LoopVar renames the loop variable; "{module}" stands for the module
+	// basename. Empty keeps item.
+	LoopVar string
+	// Label labels loop items in main.yml by a key option: LabelAuto or
+	// an option name. Empty labels them only when they may hold secrets.
+	Label string
+	// Naming names files, variables and tags: NamingShort, NamingQualified
+	// or a template using {namespace}, {collection} and {module}.
+	Naming string
+	// Prefix is prepended to every name with an underscore, such as a role
+	// name.
+	Prefix string
+	// Collisions selects how modules sharing a name are handled:
+	// CollisionsError or CollisionsQualify.
+	Collisions string
+	// Names maps modules to their names, as resolved by WithNames.
+	Names map[string]string
+	// Layout places task files below the output directory. The empty
+	// value is LayoutFlat.
+	Layout Layout
+	// SubMains writes a main.yml per collection directory, which the
+	// top-level main.yml includes. It has no effect with LayoutFlat.
+	SubMains bool
+	// Keywords lists the task keywords read from the item's _task key,
+	// out of TaskKeywords.
+	Keywords []string
+}
+
+// Marker is the comment generated files start with, after the document
+// start. extract and scan only overwrite files that carry it.
+const Marker = "# Generated by atcg. Edits are lost when it runs again."
+
 // Global templates for easier testing.
-var TaskTemplate = `---
-- name: Configure {{ .Module | basename }}
+var TaskTemplate = "---\n" + Marker + `
+{{ if .Comments }}{{ .Header }}{{ end -}}
+{{ if .Guards }}{{ .Assert }}{{ end -}}
+- name: Configure {{ .Name }}
   {{ .Module }}:
-{{- range $key, $option := .Options }}
-    {{ $key }}: "{{ "{{ item." }}{{ $key }}{{ if $option.Required }}{{ "" }}{{ else if $option.Default }}{{ " | default('" }}{{ $option.Default }}{{ "')" }}{{ else }}{{ " | default(omit)" }}{{ end }} }}"
+{{- range .Fields }}{{ $key := .Name }}{{ $option := .Option }}
+{{ if .Section }}    # --- {{ .Section }} ---
+{{ end }}{{ if $.Comments }}{{ comment $option }}{{ end }}    {{ $key }}: "{{ "{{ " }}{{ ref $key }}{{ if eq $key $.KeyOption }}{{ " | default(" }}{{ $.Key }}{{ ")" }}{{ else if $option.Required }}{{ if $.Guards }}{{ mandatory $key }}{{ end }}{{ else if $option.Default }}{{ " | default('" }}{{ $option.Default }}{{ "')" }}{{ else }}{{ " | default(omit)" }}{{ end }} }}"
 {{- end }}
-  tags: [{{ .Module | basename }}]
+{{- with .Keywords }}
+{{ . }}
+{{- end }}
+{{- if .NoLog }}
+  no_log: "{{ .NoLog }}"
+{{- end }}
+  tags: [{{ .Name }}]
 `
 
-var MainTemplate = `---
+var MainTemplate = "---\n" + Marker + `
 {{- range $index, $module := .Modules }}
 - name: Configure {{ $module.Basename }}
   ansible.builtin.include_tasks:
-    file: {{ $module.Basename }}.yml
+    file: {{ taskFile $module }}
     apply:
       tags: {{ $module.Basename }}
+{{- if eq $.Loop "single" }}
+  when: {{ $module.Basename }}_enabled | default(false) | bool
+{{- else }}
   when: {{ $module.Basename }} is defined
-  loop: "{{ "{{ " }}{{ $module.Basename }}{{ " }}" }}"
+  loop: "{{ "{{ " }}{{ $module.Basename }}{{ if eq $.Loop "dict" }} | dict2items{{ end }}{{ " }}" }}"
+{{- if or $.Guards $module.Label $.LoopVar }}
+  loop_control:
+{{- with loopVar $module.Basename }}
+    loop_var: {{ . }}
+{{- end }}
+{{- if $.Guards }}
+    index_var: {{ indexVar $module.Basename }}
+{{- end }}
+{{- if $module.Label }}
+    label: "{{ $module.Label }}"
+{{- end }}
+{{- end }}
+{{- end }}
   tags: {{ $module.Basename }}
 {{ end -}}
 `
 
 // GenerateTask generates a YAML task from the module schema.
-func GenerateTask(module string, doc *atcgModules.ModuleDoc) (string, error) {
+func GenerateTask(module string, doc *atcgModules.ModuleDoc, opts Options) (string, error) {
 	// Ensure at least one option exists
 	if len(doc.Options) == 0 {
 		return "", fmt.Errorf("doc.Options cannot be empty")
 	}
 
+	fields, err := orderFields(doc, opts)
+	if err != nil {
+		return "", err
+	}
+
+	basename := opts.Name(module)
+	if _, err := ParseLoop(string(opts.Loop)); err != nil {
+		return "", err
+	}
+	if err := ValidateKeywords(opts.Keywords); err != nil {
+		return "", err
+	}
+	vars := newItemVars(basename, opts)
+	key := ""
+	if vars.loop == LoopDict {
+		key = keyOption(doc, opts.Label, opts.SensitivePatterns)
+	}
+
+	noLog := ""
+	if opts.NoLog && len(SensitiveOptions(doc, opts.SensitivePatterns)) > 0 {
+		noLog = noLogValue(basename)
+	}
+
 	// Define the template function map
 	funcMap := template.FuncMap{
 		"replace": strings.ReplaceAll,
@@ -53,6 +165,13 @@ func GenerateTask(module string, doc *atcgModules.ModuleDoc) (string, error) {
 			parts := strings.Split(s, ".")
 			return parts[len(parts)-1]
 		},
+		"comment": func(option atcgModules.ModuleOption) string {
+			return optionComment(option, "    ")
+		},
+		"mandatory": func(name string) string {
+			return mandatoryFilter(vars, name)
+		},
+		"ref": vars.ref,
 	}
 
 	// Parse the task template
@@ -64,40 +183,92 @@ func GenerateTask(module string, doc *atcgModules.ModuleDoc) (string, error) {
 	// Execute the template with the provided data
 	var output strings.Builder
 	if err := tmpl.Execute(&output, map[string]interface{}{
-		"Module":  module,
-		"Options": doc.Options,
+		"Module":    module,
+		"Name":      basename,
+		"Fields":    fields,
+		"Comments":  opts.Comments,
+		"Header":    moduleHeader(module, doc, opts.Inventory),
+		"Guards":    opts.Guards,
+		"Assert":    assertTask(vars, fields, key, len(opts.Keywords) > 0),
+		"Keywords":  strings.TrimSuffix(taskKeywords(vars, opts.Keywords), "\n"),
+		"NoLog":     noLog,
+		"KeyOption": key,
+		"Key":       vars.key(),
 	}); err != nil {
 		return "", fmt.Errorf("executing task template: %w", err)
 	}
 
+	if err := ValidateTask(opts.TaskFile(module), module, output.String()); err != nil {
+		return "", fmt.Errorf("generated invalid YAML: %w", err)
+	}
+
 	return output.String(), nil
 }
 
 // GenerateMain generates the main.yml file with include_tasks for each module.
-func GenerateMain(modules []Module, outputDir string) error {
+// With sub-mains, one main.yml per collection directory is written as well.
+func GenerateMain(modules []Module, outputDir string, opts Options) error {
+	mains, err := RenderMains(modules, opts)
+	if err != nil {
+		return err
+	}
+
+	for name, content := range mains {
+		mainFile, err := OutputPath(outputDir, name)
+		if err != nil {
+			return err
+		}
+		if err := makeParentDir(outputDir, name); err != nil {
+			return err
+		}
+		if err := os.WriteFile(mainFile, []byte(content), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// RenderMain renders the content of main.yml without writing it.
+func RenderMain(modules []Module, opts Options) (string, error) {
 	// Ensure modules have valid Basenames
 	for _, module := range modules {
 		if strings.TrimSpace(module.Basename) == "" {
-			return fmt.Errorf("module.Basename cannot be empty")
+			return "", fmt.Errorf("module.Basename cannot be empty")
 		}
 	}
 
-	tmpl, err := template.New("main").Parse(MainTemplate)
+	loop, err := ParseLoop(string(opts.Loop))
+	if err != nil {
+		return "", err
+	}
+
+	funcMap := template.FuncMap{
+		"indexVar": indexVar,
+		"taskFile": taskFile,
+		"loopVar": func(basename string) string {
+			return newItemVars(basename, opts).loopVar
+		},
+	}
+
+	tmpl, err := template.New("main").Funcs(funcMap).Parse(MainTemplate)
 	if err != nil {
-		return fmt.Errorf("parsing main template: %w", err)
+		return "", fmt.Errorf("parsing main template: %w", err)
 	}
 
 	var output strings.Builder
 	if err := tmpl.Execute(&output, map[string]interface{}{
 		"Modules": modules,
+		"Guards":  opts.Guards,
+		"Loop":    string(loop),
+		"LoopVar": opts.LoopVar,
 	}); err != nil {
-		return fmt.Errorf("executing main template: %w", err)
+		return "", fmt.Errorf("executing main template: %w", err)
 	}
 
-	mainFile := filepath.Join(outputDir, "main.yml")
-	if err := os.WriteFile(mainFile, []byte(output.String()), 0644); err != nil {
-		return fmt.Errorf("writing main.yml: %w", err)
+	if err := ValidateMain("main.yml", output.String()); err != nil {
+		return "", fmt.Errorf("generated invalid YAML: %w", err)
 	}
 
-	return nil
+	return output.String(), nil
 }
@@ -14,7 +14,7 @@ func TestGenerateMain_Success(t *testing.T) {
 	}
 	outputDir := t.TempDir()
 
-	err := GenerateMain(modules, outputDir)
+	err := GenerateMain(modules, outputDir, Options{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -27,6 +27,7 @@ func TestGenerateMain_Success(t *testing.T) {
 	}
 
 	expectedContent := `---
+# Generated by atcg. Edits are lost when it runs again.
 - name: Configure debug
   ansible.builtin.include_tasks:
     file: debug.yml
@@ -62,7 +63,7 @@ func TestGenerateMain_TemplateParsingError(t *testing.T) {
 	}
 	outputDir := t.TempDir()
 
-	err := GenerateMain(modules, outputDir)
+	err := GenerateMain(modules, outputDir, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -117,7 +118,7 @@ func TestGenerateMain_ErrorCases(t *testing.T) {
 			}
 
 			// Run GenerateMain
-			err := GenerateMain(tt.modules, tt.outputDir)
+			err := GenerateMain(tt.modules, tt.outputDir, Options{})
 			if err == nil {
 				t.Fatal("expected an error, got nil")
 			}
@@ -20,12 +20,13 @@ func TestGenerateTask_Success(t *testing.T) {
 		},
 	}
 
-	output, err := GenerateTask(module, doc)
+	output, err := GenerateTask(module, doc, Options{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
 	expectedOutput := `---
+# Generated by atcg. Edits are lost when it runs again.
 - name: Configure debug
   ansible.builtin.debug:
     msg: "{{ item.msg | default('Hello, World!') }}"
@@ -42,7 +43,7 @@ func TestGenerateTask_NilOptions(t *testing.T) {
 		Options: nil, // Invalid case
 	}
 
-	_, err := GenerateTask(module, doc)
+	_, err := GenerateTask(module, doc, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -59,7 +60,7 @@ func TestGenerateTask_EmptyOptions(t *testing.T) {
 		Options: map[string]atcgModules.ModuleOption{}, // Empty options
 	}
 
-	_, err := GenerateTask(module, doc)
+	_, err := GenerateTask(module, doc, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -88,7 +89,7 @@ func TestGenerateTask_TemplateParsingError(t *testing.T) {
 		},
 	}
 
-	_, err := GenerateTask(module, doc)
+	_, err := GenerateTask(module, doc, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -110,7 +111,7 @@ func TestGenerateTask_TemplateExecutionError(t *testing.T) {
 	}
 
 	// Call GenerateTask and expect it to fail
-	_, err := GenerateTask(module, doc)
+	_, err := GenerateTask(module, doc, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -120,3 +121,51 @@ func TestGenerateTask_TemplateExecutionError(t *testing.T) {
 		t.Errorf("unexpected error message: %v", err)
 	}
 }
+
+func TestGenerateTask_Comments(t *testing.T) {
+	module := "ansible.windows.win_user_right"
+	doc := &atcgModules.ModuleDoc{
+		Collection:       "ansible.windows",
+		ShortDescription: "Manage Windows User Rights",
+		Options: map[string]atcgModules.ModuleOption{
+			"action": {
+				Choices:     []interface{}{"add", "remove", "set"},
+				Default:     "set",
+				Description: []string{"C(add) will add the users/groups to the existing right.", "Second paragraph."},
+				Type:        "str",
+			},
+			"name": {
+				Description:  []string{"The name of the User Right as shown by the C(Constant Name) value from U(https://technet.microsoft.com/en-us/library/dd349804.aspx)."},
+				Required:     true,
+				Type:         "str",
+				Aliases:      []string{"right"},
+				VersionAdded: "1.2.0",
+			},
+		},
+	}
+	inventory := &atcgModules.Inventory{Collections: []atcgModules.Collection{{Name: "ansible.windows", Version: "2.0.0"}}}
+
+	output, err := GenerateTask(module, doc, Options{Comments: true, Inventory: inventory})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expectedOutput := `---
+# Generated by atcg. Edits are lost when it runs again.
+# ansible.windows.win_user_right - Manage Windows User Rights
+# Source: ansible.windows 2.0.0
+- name: Configure win_user_right
+  ansible.windows.win_user_right:
+    # ` + "`add`" + ` will add the users/groups to the existing right.
+    # type: str | choices: add, remove, set | default: set
+    action: "{{ item.action | default('set') }}"
+    # The name of the User Right as shown by the ` + "`Constant Name`" + ` value from
+    # https://technet.microsoft.com/en-us/library/dd349804.aspx.
+    # type: str | required | aliases: right | added in: 1.2.0
+    name: "{{ item.name }}"
+  tags: [win_user_right]
+`
+	if output != expectedOutput {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", output, expectedOutput)
+	}
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indexVar names the loop index main.yml passes to a task file when guards
+// are enabled.
+func indexVar(basename string) string {
+	return basename + "_index"
+}
+
+// mandatoryFilter renders the filter guarding a required option.
+func mandatoryFilter(vars itemVars, name string) string {
+	return " | mandatory('" + vars.basename + " requires " + vars.ref(name) + "')"
+}
+
+// assertTask renders the task validating each loop item before the module
+// runs: that it is a mapping, has no unknown keys, holds every required
+// option, and that values match the documented type and choices. Flat
+// variables are only checked for required options, types and choices.
+// keyOption is filled from the dict key and need not be set. With task
+// keywords, the _task key must hold a mapping.
+func assertTask(vars itemVars, fields []field, keyOption string, keywords bool) string {
+	basename := vars.basename
+	var that []string
+
+	if item := vars.item(); item != "" {
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+		}
+		if keywords {
+			names = append(names, TaskKey)
+		}
+		sort.Strings(names)
+		that = append(that,
+			item+" is mapping",
+			item+".keys() | difference("+jinjaLiteral(stringsToValues(names))+") | length == 0")
+	}
+	if keywords {
+		that = append(that, vars.taskRef()+" is not defined or "+vars.taskRef()+" is mapping")
+	}
+
+	for _, f := range fields {
+		ref := vars.ref(f.Name)
+		if f.Option.Required && f.Name != keyOption {
+			that = append(that, ref+" is defined")
+		}
+		if check := typeCheck(ref, f.Option.Type); check != "" {
+			that = append(that, ref+" is not defined or "+check)
+		}
+		if len(f.Option.Choices) > 0 {
+			choices := jinjaLiteral(f.Option.Choices)
+			if f.Option.Type == "list" {
+				that = append(that, ref+" is not defined or "+ref+" | difference("+choices+") | length == 0")
+			} else {
+				that = append(that, ref+" is not defined or "+ref+" in "+choices)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("- name: Validate " + basename + "\n")
+	b.WriteString("  ansible.builtin.assert:\n")
+	b.WriteString("    that:\n")
+	for _, expr := range that {
+		b.WriteString("      - " + doubleQuote(expr) + "\n")
+	}
+	failMsg := basename + " item {{ " + indexVar(basename) + " | default('?') }} is invalid, see the failed assertion"
+	if vars.loop == LoopSingle {
+		failMsg = basename + " variables are invalid, see the failed assertion"
+	}
+	b.WriteString("    fail_msg: " + doubleQuote(failMsg) + "\n")
+	b.WriteString("    quiet: true\n")
+	b.WriteString("  tags: [" + basename + "]\n")
+	b.WriteString("\n")
+	return b.String()
+}
+
+// typeCheck returns a Jinja test that ref holds a value Ansible accepts for
+// the option type, or "" when the type is not checked.
+func typeCheck(ref, optionType string) string {
+	switch optionType {
+	case "str":
+		return "(" + ref + " is string or " + ref + " is number or " + ref + " is boolean)"
+	case "int":
+		return "(" + ref + " is integer or " + ref + " | string is match('^[+-]?[0-9]+$'))"
+	case "float":
+		return "(" + ref + " is number or " + ref + " | string is match('^[+-]?([0-9]+[.]?[0-9]*|[.][0-9]+)([eE][+-]?[0-9]+)?$'))"
+	case "bool":
+		return "(" + ref + " is boolean or " + ref + " | string | lower in ['yes', 'no', 'true', 'false', 'on', 'off', 'y', 'n', 't', 'f', '1', '0'])"
+	case "list":
+		return "(" + ref + " is iterable and " + ref + " is not mapping)"
+	case "dict":
+		return "(" + ref + " is mapping or " + ref + " is string)"
+	}
+	return ""
+}
+
+// jinjaLiteral renders a value decoded from JSON as a Jinja literal.
+func jinjaLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "none"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = jinjaLiteral(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return jinjaLiteral(fmt.Sprint(v))
+}
+
+func stringsToValues(s []string) []interface{} {
+	values := make([]interface{}, len(s))
+	for i, v := range s {
+		values[i] = v
+	}
+	return values
+}
+
+// doubleQuote renders s as a double-quoted YAML scalar.
+func doubleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
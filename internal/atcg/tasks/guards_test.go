@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestGenerateTask_Guards(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{
+		Options: map[string]atcgModules.ModuleOption{
+			"name":   {Required: true, Type: "str"},
+			"action": {Type: "str", Choices: []interface{}{"add", "remove", "it's"}, Default: "set"},
+			"users":  {Type: "list"},
+			"path":   {Type: "path"},
+		},
+	}
+
+	got, err := GenerateTask("ansible.windows.win_user_right", doc, Options{Guards: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Validate win_user_right
+  ansible.builtin.assert:
+    that:
+      - "item is mapping"
+      - "item.keys() | difference(['action', 'name', 'path', 'users']) | length == 0"
+      - "item.action is not defined or (item.action is string or item.action is number or item.action is boolean)"
+      - "item.action is not defined or item.action in ['add', 'remove', 'it\\'s']"
+      - "item.name is defined"
+      - "item.name is not defined or (item.name is string or item.name is number or item.name is boolean)"
+      - "item.users is not defined or (item.users is iterable and item.users is not mapping)"
+    fail_msg: "win_user_right item {{ win_user_right_index | default('?') }} is invalid, see the failed assertion"
+    quiet: true
+  tags: [win_user_right]
+
+- name: Configure win_user_right
+  ansible.windows.win_user_right:
+    action: "{{ item.action | default('set') }}"
+    name: "{{ item.name | mandatory('win_user_right requires item.name') }}"
+    path: "{{ item.path | default(omit) }}"
+    users: "{{ item.users | default(omit) }}"
+  tags: [win_user_right]
+`
+	if got != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	var parsed []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("generated task is not valid YAML: %v", err)
+	}
+	that := parsed[0]["ansible.builtin.assert"].(map[string]interface{})["that"].([]interface{})
+	if !strings.HasSuffix(that[3].(string), `'it\'s']`) {
+		t.Errorf("unexpected choices assertion %q", that[3])
+	}
+}
+
+func TestRenderMain_Guards(t *testing.T) {
+	got, err := RenderMain([]Module{{Name: "ansible.builtin.debug", Basename: "debug"}}, Options{Guards: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !strings.Contains(got, "  loop: \"{{ debug }}\"\n  loop_control:\n    index_var: debug_index\n  tags: debug\n") {
+		t.Errorf("expected an index_var, got:\n%s", got)
+	}
+}
+
+func TestJinjaLiteral(t *testing.T) {
+	got := jinjaLiteral([]interface{}{"a", 1.5, true, nil, `b\c`})
+	if expected := `['a', 1.5, true, none, 'b\\c']`; got != expected {
+		t.Errorf("got %s, want %s", got, expected)
+	}
+}
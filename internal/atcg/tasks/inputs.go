@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"strings"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// Inputs describes the variables the task file of a module reads, for
+// checking hand-written vars against it.
+type Inputs struct {
+	// Variable is the list or dict main.yml loops over. With LoopSingle the
+	// options are read from flat variables named Variable_<option>.
+	Variable string
+	Loop     Loop
+	// KeyOption is filled from the dict key with LoopDict, or "".
+	KeyOption string
+	// Keywords lists the task keywords an item may set below TaskKey.
+	Keywords []string
+}
+
+// Inputs returns the variables the task file of module reads.
+func (o Options) Inputs(module string, doc *atcgModules.ModuleDoc) Inputs {
+	vars := newItemVars(o.Name(module), o)
+	inputs := Inputs{Variable: vars.basename, Loop: vars.loop, Keywords: o.Keywords}
+	if vars.loop == LoopDict {
+		inputs.KeyOption = keyOption(doc, o.Label, o.SensitivePatterns)
+	}
+	return inputs
+}
+
+// Flat returns the flat variable holding an option with LoopSingle.
+func (i Inputs) Flat(option string) string {
+	return i.Variable + "_" + option
+}
+
+// Owns reports whether key is a flat variable the task file reads with
+// LoopSingle, and returns the option it holds: "" for enabled, no_log and,
+// with Keywords, the task keywords.
+func (i Inputs) Owns(key string) (string, bool) {
+	if i.Loop != LoopSingle {
+		return "", false
+	}
+	suffix, ok := strings.CutPrefix(key, i.Variable+"_")
+	if !ok {
+		return "", false
+	}
+	if suffix == "enabled" || suffix == "no_log" || (len(i.Keywords) > 0 && key == i.Variable+TaskKey) {
+		return "", true
+	}
+	return suffix, true
+}
@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestInputs(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":     {Type: "str"},
+		"password": {Type: "str"},
+	}}
+
+	got := Options{}.Inputs("ansible.windows.win_user", doc)
+	if want := (Inputs{Variable: "win_user", Loop: LoopList}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = Options{Loop: LoopDict, Prefix: "role_", Keywords: []string{"when"}}.Inputs("ansible.windows.win_user", doc)
+	want := Inputs{Variable: "role_win_user", Loop: LoopDict, KeyOption: "name", Keywords: []string{"when"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got = Options{Loop: LoopSingle}.Inputs("ansible.windows.win_user", doc)
+	if flat := got.Flat("password"); flat != "win_user_password" {
+		t.Errorf("got flat variable %q", flat)
+	}
+}
+
+func TestInputs_Owns(t *testing.T) {
+	inputs := Inputs{Variable: "win_user", Loop: LoopSingle, Keywords: []string{"when"}}
+	for key, want := range map[string]string{
+		"win_user_password": "password",
+		"win_user_enabled":  "",
+		"win_user_no_log":   "",
+		"win_user_task":     "",
+	} {
+		if option, ok := inputs.Owns(key); !ok || option != want {
+			t.Errorf("%s: got %q, %v, want %q", key, option, ok, want)
+		}
+	}
+	for _, key := range []string{"win_user", "win_username", "other_password"} {
+		if _, ok := inputs.Owns(key); ok {
+			t.Errorf("%s: expected no match", key)
+		}
+	}
+	if option, ok := (Inputs{Variable: "win_user", Loop: LoopSingle}).Owns("win_user_task"); !ok || option != "task" {
+		t.Errorf("expected an option without keywords, got %q, %v", option, ok)
+	}
+	if _, ok := (Inputs{Variable: "win_user", Loop: LoopList}).Owns("win_user_password"); ok {
+		t.Errorf("expected no flat variables for a list loop")
+	}
+}
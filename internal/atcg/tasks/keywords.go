@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskKey is the reserved item key holding task keywords.
+const TaskKey = "_task"
+
+// TaskKeywords lists the task keywords that may be passed through items.
+var TaskKeywords = []string{
+	"async", "become", "become_method", "become_user", "changed_when",
+	"check_mode", "delay", "delegate_to", "diff", "environment",
+	"failed_when", "ignore_errors", "poll", "retries", "run_once",
+	"throttle", "timeout", "until", "when",
+}
+
+// conditionals are the keywords Ansible evaluates as conditions. omit does
+// not apply to them, so they fall back to what the task would do anyway.
+var conditionals = map[string]bool{"changed_when": true, "failed_when": true, "until": true, "when": true}
+
+// IsConditional reports whether Ansible evaluates a task keyword as a
+// condition. Read from an item, such a keyword must hold a boolean or a
+// "{{ }}" expression: a bare condition is a non-empty string, which is
+// always true.
+func IsConditional(keyword string) bool {
+	return conditionals[keyword]
+}
+
+// Condition turns the bare conditions of a task keyword, which are ANDed
+// when there are several, into the "{{ }}" expression an item holds.
+func Condition(conditions []string) string {
+	if len(conditions) == 1 {
+		return "{{ " + conditions[0] + " }}"
+	}
+	parts := make([]string, len(conditions))
+	for i, condition := range conditions {
+		parts[i] = "(" + condition + ")"
+	}
+	return "{{ " + strings.Join(parts, " and ") + " }}"
+}
+
+// ValidateKeywords checks an allow-list of task keywords.
+func ValidateKeywords(keywords []string) error {
+	for _, keyword := range keywords {
+		if !isTaskKeyword(keyword) {
+			return fmt.Errorf("unsupported task keyword %q (want any of %s)", keyword, strings.Join(TaskKeywords, ", "))
+		}
+	}
+	return nil
+}
+
+func isTaskKeyword(keyword string) bool {
+	for _, known := range TaskKeywords {
+		if known == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// taskRef is the expression holding the task keywords of an item.
+func (v itemVars) taskRef() string {
+	if v.loop == LoopSingle {
+		return v.basename + TaskKey
+	}
+	return v.item() + "." + TaskKey
+}
+
+// registerVar names the variable the task result is registered in when a
+// conditional keyword needs it.
+func registerVar(basename string) string {
+	return basename + "_result"
+}
+
+// taskKeywords renders the keywords of the allow-list, in TaskKeywords
+// order, reading them from the item's _task key.
+func taskKeywords(vars itemVars, keywords []string) string {
+	allowed := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		allowed[keyword] = true
+	}
+
+	var b strings.Builder
+	result := registerVar(vars.basename)
+	for _, keyword := range TaskKeywords {
+		ref := vars.taskRef() + "." + keyword
+		// A task with until is retried 3 times unless retries says
+		// otherwise, so items without until get a single attempt.
+		if keyword == "retries" && allowed["until"] {
+			b.WriteString("  retries: " + doubleQuote("{{ "+ref+" | default(omit if "+vars.taskRef()+".until is defined else 0) }}") + "\n")
+			continue
+		}
+		if !allowed[keyword] {
+			continue
+		}
+		switch keyword {
+		case "when":
+			b.WriteString("  when: " + doubleQuote(ref+" | default(true)") + "\n")
+		case "changed_when":
+			b.WriteString("  changed_when: " + doubleQuote(ref+" | default("+result+".changed | default(false))") + "\n")
+		case "failed_when":
+			b.WriteString("  failed_when: " + doubleQuote(ref+" | default("+result+".failed | default(false))") + "\n")
+		case "until":
+			b.WriteString("  until: " + doubleQuote(ref+" | default("+result+" is succeeded)") + "\n")
+		default:
+			b.WriteString("  " + keyword + ": " + doubleQuote("{{ "+ref+" | default(omit) }}") + "\n")
+		}
+	}
+
+	for keyword := range allowed {
+		if conditionals[keyword] && keyword != "when" {
+			return "  register: " + result + "\n" + b.String()
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestGenerateTask_Keywords(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	opts := Options{Keywords: []string{"when", "become", "until", "retries", "environment"}}
+	got, err := GenerateTask("ansible.windows.win_service", doc, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Configure win_service
+  ansible.windows.win_service:
+    name: "{{ item.name }}"
+    state: "{{ item.state | default('present') }}"
+  register: win_service_result
+  become: "{{ item._task.become | default(omit) }}"
+  environment: "{{ item._task.environment | default(omit) }}"
+  retries: "{{ item._task.retries | default(omit if item._task.until is defined else 0) }}"
+  until: "item._task.until | default(win_service_result is succeeded)"
+  when: "item._task.when | default(true)"
+  tags: [win_service]
+`
+	if got != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Errorf("generated task is not valid YAML: %v", err)
+	}
+}
+
+func TestTaskKeywords_UntilWithoutRetries(t *testing.T) {
+	got := taskKeywords(newItemVars("ping", Options{}), []string{"until"})
+	expected := `  register: ping_result
+  retries: "{{ item._task.retries | default(omit if item._task.until is defined else 0) }}"
+  until: "item._task.until | default(ping_result is succeeded)"
+`
+	if got != expected {
+		t.Errorf("got:\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestGenerateTask_KeywordsSingleWithGuards(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	opts := Options{Keywords: []string{"delegate_to"}, Loop: LoopSingle, Guards: true}
+	got, err := GenerateTask("ansible.windows.win_service", doc, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, want := range []string{
+		`      - "win_service_task is not defined or win_service_task is mapping"`,
+		`  delegate_to: "{{ win_service_task.delegate_to | default(omit) }}"`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "register:") {
+		t.Errorf("unexpected register in:\n%s", got)
+	}
+}
+
+func TestGenerateTask_KeywordsGuardKeys(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	got, err := GenerateTask("ansible.windows.win_service", doc, Options{Keywords: []string{"become"}, Guards: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(got, `"item.keys() | difference(['_task', 'name', 'state']) | length == 0"`) {
+		t.Errorf("expected _task to be an allowed key:\n%s", got)
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"msg": {}}}
+	if err := ValidateKeywords([]string{"become", "async"}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := ValidateKeywords([]string{"register"}); err == nil || !strings.Contains(err.Error(), `unsupported task keyword "register"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := GenerateTask("ansible.builtin.debug", doc, Options{Keywords: []string{"loop"}}); err == nil {
+		t.Error("expected GenerateTask to reject unsupported keywords")
+	}
+}
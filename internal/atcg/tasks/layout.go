@@ -0,0 +1,239 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Layout selects where task files are written below the output directory.
+type Layout string
+
+const (
+	// LayoutFlat writes every task file to the output directory. It is the
+	// default.
+	LayoutFlat Layout = "flat"
+	// LayoutFQCN mirrors the FQCN, as in ansible/windows/win_user_right.yml.
+	LayoutFQCN Layout = "fqcn"
+	// LayoutCollection groups by collection, as in
+	// ansible.windows/win_user_right.yml.
+	LayoutCollection Layout = "collection"
+)
+
+// Layouts lists the supported layouts.
+var Layouts = []Layout{LayoutFlat, LayoutFQCN, LayoutCollection}
+
+// ParseLayout validates a layout name. The empty string is LayoutFlat.
+func ParseLayout(s string) (Layout, error) {
+	if s == "" {
+		return LayoutFlat, nil
+	}
+	for _, layout := range Layouts {
+		if string(layout) == s {
+			return layout, nil
+		}
+	}
+	return "", fmt.Errorf("unknown layout %q (want one of %v)", s, Layouts)
+}
+
+// CollectionsTemplate renders main.yml when every collection has a main.yml
+// of its own. Each include is tagged with the tags of its modules so that
+// --tags selects it.
+var CollectionsTemplate = "---\n" + Marker + `
+{{- range .Collections }}
+- name: Configure {{ .Name }}
+  ansible.builtin.include_tasks:
+    file: {{ .File }}
+  tags: [{{ join .Tags ", " }}]
+{{ end -}}
+`
+
+// collectionDir returns the directory, relative to the output directory and
+// slash-separated, that the task file of module goes to.
+func (o Options) collectionDir(module string) string {
+	parts := strings.Split(module, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	switch o.Layout {
+	case LayoutFQCN:
+		return path.Join(parts[:len(parts)-1]...)
+	case LayoutCollection:
+		return strings.Join(parts[:len(parts)-1], ".")
+	}
+	return ""
+}
+
+// TaskFile returns the task file of module relative to the output
+// directory, slash-separated.
+func (o Options) TaskFile(module string) string {
+	return path.Join(o.collectionDir(module), o.Name(module)+".yml")
+}
+
+// taskFile returns the file main.yml includes for m.
+func taskFile(m Module) string {
+	if m.Path != "" {
+		return m.Path
+	}
+	return m.Basename + ".yml"
+}
+
+// collectionMain is an entry of CollectionsTemplate.
+type collectionMain struct {
+	Name string
+	File string
+	Tags []string
+}
+
+// RenderMains renders main.yml and, with SubMains and a layout other than
+// LayoutFlat, one main.yml per collection directory. The result maps paths
+// relative to the output directory to their content.
+func RenderMains(modules []Module, opts Options) (map[string]string, error) {
+	layout, err := ParseLayout(string(opts.Layout))
+	if err != nil {
+		return nil, err
+	}
+	if !opts.SubMains || layout == LayoutFlat {
+		main, err := RenderMain(modules, opts)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"main.yml": main}, nil
+	}
+
+	var dirs []string
+	byDir := make(map[string][]Module)
+	for _, module := range modules {
+		dir := path.Dir(taskFile(module))
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		module.Path = path.Base(taskFile(module))
+		byDir[dir] = append(byDir[dir], module)
+	}
+
+	mains := make(map[string]string, len(dirs)+1)
+	var collections []collectionMain
+	for _, dir := range dirs {
+		main, err := RenderMain(byDir[dir], opts)
+		if err != nil {
+			return nil, err
+		}
+		file := path.Join(dir, "main.yml")
+		mains[file] = main
+
+		entry := collectionMain{Name: strings.ReplaceAll(dir, "/", "."), File: file}
+		for _, module := range byDir[dir] {
+			entry.Tags = append(entry.Tags, module.Basename)
+		}
+		collections = append(collections, entry)
+	}
+
+	tmpl, err := template.New("collections").Funcs(template.FuncMap{"join": strings.Join}).Parse(CollectionsTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing collections template: %w", err)
+	}
+	var output strings.Builder
+	if err := tmpl.Execute(&output, map[string]interface{}{"Collections": collections}); err != nil {
+		return nil, fmt.Errorf("executing collections template: %w", err)
+	}
+	if err := ValidateMain("main.yml", output.String()); err != nil {
+		return nil, fmt.Errorf("generated invalid YAML: %w", err)
+	}
+	if _, ok := mains["main.yml"]; ok {
+		return nil, fmt.Errorf("modules without a collection cannot be mixed with per-collection main files")
+	}
+	mains["main.yml"] = output.String()
+
+	return mains, nil
+}
+
+// OutputPath returns the file the slash-separated name stands for below
+// outputDir. Absolute names and names leaving the output directory through
+// ".." are an error, so that no generated file is written outside it.
+func OutputPath(outputDir, name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("output file %s is outside the output directory %s", name, outputDir)
+	}
+	return filepath.Join(outputDir, local), nil
+}
+
+// Outputs returns the files, relative to the output directory and
+// slash-separated, that generating modules writes: their task files, main.yml
+// and, with SubMains, the main.yml of each collection directory.
+func (o Options) Outputs(modules []string) []string {
+	outputs := []string{"main.yml"}
+	seen := map[string]bool{"main.yml": true}
+	for _, module := range modules {
+		file := o.TaskFile(module)
+		names := []string{file}
+		if o.SubMains && o.Layout != LayoutFlat && path.Dir(file) != "." {
+			names = append(names, path.Join(path.Dir(file), "main.yml"))
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				outputs = append(outputs, name)
+			}
+		}
+	}
+	return outputs
+}
+
+// CheckOutputs makes sure that writing the outputs below outputDir loses no
+// work: an existing file must have been generated by atcg, and no output
+// may be one of inputs, the files the modules were found in.
+func CheckOutputs(outputDir string, outputs, inputs []string) error {
+	read := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		if abs, err := filepath.Abs(input); err == nil {
+			read[abs] = input
+		}
+	}
+	for _, name := range outputs {
+		file, err := OutputPath(outputDir, name)
+		if err != nil {
+			return err
+		}
+		if abs, err := filepath.Abs(file); err == nil && read[abs] != "" {
+			return fmt.Errorf("%s is one of the files read, choose another output directory with -o", read[abs])
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if !IsGenerated(content) {
+			return fmt.Errorf("%s exists and was not generated by atcg, choose another output directory with -o", file)
+		}
+	}
+	return nil
+}
+
+// IsGenerated reports whether content was written by atcg, that is starts
+// with Marker.
+func IsGenerated(content []byte) bool {
+	return strings.HasPrefix(strings.TrimPrefix(string(content), "---\n"), Marker)
+}
+
+// makeParentDir creates the directories between outputDir and the
+// slash-separated name below it. The output directory itself must exist.
+func makeParentDir(outputDir, name string) error {
+	if _, err := OutputPath(outputDir, name); err != nil {
+		return err
+	}
+	dir := path.Dir(name)
+	if dir == "." {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(outputDir, filepath.FromSlash(dir)), 0755); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"atcg/internal/atcg/mocks"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestOptions_TaskFile(t *testing.T) {
+	tests := []struct {
+		layout   Layout
+		module   string
+		expected string
+	}{
+		{layout: "", module: "ansible.windows.win_user_right", expected: "win_user_right.yml"},
+		{layout: LayoutFQCN, module: "ansible.windows.win_user_right", expected: "ansible/windows/win_user_right.yml"},
+		{layout: LayoutCollection, module: "ansible.windows.win_user_right", expected: "ansible.windows/win_user_right.yml"},
+		{layout: LayoutFQCN, module: "debug", expected: "debug.yml"},
+	}
+
+	for _, tt := range tests {
+		if got := (Options{Layout: tt.layout}).TaskFile(tt.module); got != tt.expected {
+			t.Errorf("%s %s: got %q, want %q", tt.layout, tt.module, got, tt.expected)
+		}
+	}
+
+	if _, err := ParseLayout("tree"); err == nil || !strings.Contains(err.Error(), `unknown layout "tree"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRenderMains_SubMains(t *testing.T) {
+	opts := Options{Layout: LayoutFQCN, SubMains: true}
+	modules := []Module{
+		{Name: "ansible.windows.win_service", Basename: "win_service", Path: opts.TaskFile("ansible.windows.win_service")},
+		{Name: "ansible.builtin.debug", Basename: "debug", Path: opts.TaskFile("ansible.builtin.debug")},
+		{Name: "ansible.windows.win_user", Basename: "win_user", Path: opts.TaskFile("ansible.windows.win_user")},
+	}
+
+	mains, err := RenderMains(modules, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(mains) != 3 {
+		t.Fatalf("expected 3 main files, got %v", mains)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Configure ansible.windows
+  ansible.builtin.include_tasks:
+    file: ansible/windows/main.yml
+  tags: [win_service, win_user]
+
+- name: Configure ansible.builtin
+  ansible.builtin.include_tasks:
+    file: ansible/builtin/main.yml
+  tags: [debug]
+`
+	if mains["main.yml"] != expected {
+		t.Errorf("unexpected main.yml:\n%s\nexpected:\n%s", mains["main.yml"], expected)
+	}
+	if sub := mains["ansible/windows/main.yml"]; !strings.Contains(sub, "    file: win_service.yml\n") || !strings.Contains(sub, "    file: win_user.yml\n") {
+		t.Errorf("unexpected sub-main:\n%s", sub)
+	}
+
+	if _, err := RenderMains(append(modules, Module{Name: "debug", Basename: "plain"}), opts); err == nil {
+		t.Error("expected an error when mixing modules without a collection")
+	}
+}
+
+func TestRenderMains_WithoutSubMains(t *testing.T) {
+	opts := Options{Layout: LayoutCollection}
+	mains, err := RenderMains([]Module{{Name: "ansible.builtin.debug", Basename: "debug", Path: opts.TaskFile("ansible.builtin.debug")}}, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(mains) != 1 || !strings.Contains(mains["main.yml"], "    file: ansible.builtin/debug.yml\n") {
+		t.Errorf("unexpected mains %v", mains)
+	}
+}
+
+func TestProcessModule_Layout(t *testing.T) {
+	mockExecutor := &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			return []byte(`{"ansible.builtin.debug": {"doc": {"options": {"msg": {}}}}}`), nil
+		},
+	}
+
+	outputDir := t.TempDir()
+	opts := Options{Layout: LayoutFQCN, SubMains: true}
+	details, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := GenerateMain([]Module{*details}, outputDir, opts); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, file := range []string{"ansible/builtin/debug.yml", "ansible/builtin/main.yml", "main.yml"} {
+		if _, err := os.Stat(filepath.Join(outputDir, file)); err != nil {
+			t.Errorf("expected %s to exist: %v", file, err)
+		}
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.yml", "ansible/windows/win_user_right.yml", "a/../b.yml"} {
+		if _, err := OutputPath(dir, name); err != nil {
+			t.Errorf("OutputPath(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"../x.yml", "a/../../x.yml", "/etc/passwd", "", ".."} {
+		if _, err := OutputPath(dir, name); err == nil {
+			t.Errorf("OutputPath(%q) = nil, want an error", name)
+		}
+	}
+
+	if _, err := writeTaskFile("---\n", "../escaped.yml", filepath.Join(dir, "out")); err == nil {
+		t.Error("expected writeTaskFile to refuse a file outside the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.yml")); err == nil {
+		t.Error("file written outside the output directory")
+	}
+}
+
+func TestCheckOutputs(t *testing.T) {
+	role := t.TempDir()
+	dir := filepath.Join(role, "tasks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{}
+	outputs := opts.Outputs([]string{"ansible.windows.win_user_right"})
+	if err := CheckOutputs(dir, outputs, nil); err != nil {
+		t.Errorf("expected no error for an empty directory, got %v", err)
+	}
+
+	// atcg extract . from a role root reads and would write tasks/main.yml.
+	main := filepath.Join(dir, "main.yml")
+	handWritten := "---\n- name: Allow logon\n  ansible.windows.win_user_right:\n    name: SeInteractiveLogonRight\n"
+	if err := os.WriteFile(main, []byte(handWritten), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckOutputs(dir, outputs, []string{main})
+	if err == nil || !strings.Contains(err.Error(), "is one of the files read") {
+		t.Errorf("unexpected error %v", err)
+	}
+	err = CheckOutputs(dir, outputs, nil)
+	if err == nil || !strings.Contains(err.Error(), "was not generated by atcg") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	generated, err := RenderMain([]Module{{Name: "ansible.windows.win_user_right", Basename: "win_user_right"}}, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(main, []byte(generated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOutputs(dir, outputs, nil); err != nil {
+		t.Errorf("expected a generated main.yml to be overwritten, got %v", err)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"marker", "---\n" + Marker + "\n- name: Configure ping\n  ansible.builtin.ping: {}\n  tags: [ping]\n", true},
+		{"renamed task", "---\n" + Marker + "\n- name: Ping the hosts\n  ansible.builtin.ping: {}\n", true},
+		{"hand-written in the same style", "---\n- name: Configure ping\n  ansible.builtin.ping: {}\n  tags: [ping]\n", false},
+		{"marker further down", "---\n- name: Configure ping\n  ansible.builtin.ping: {}\n" + Marker + "\n", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		if got := IsGenerated([]byte(tt.content)); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsGenerated_TaskFile(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"name": {Type: "str", Required: true}}}
+	task, err := GenerateTask("ansible.windows.win_user_right", doc, Options{Guards: true, Comments: true, Keywords: []string{"when"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !IsGenerated([]byte(task)) {
+		t.Errorf("expected a generated task file to be recognised\n%s", task)
+	}
+}
+
+func TestOptions_Outputs(t *testing.T) {
+	opts := Options{Layout: LayoutFQCN, SubMains: true}
+	got := opts.Outputs([]string{"ansible.windows.win_user", "ansible.windows.win_service", "debug"})
+	want := []string{"main.yml", "ansible/windows/win_user.yml", "ansible/windows/main.yml", "ansible/windows/win_service.yml", "debug.yml"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
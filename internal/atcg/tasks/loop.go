@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Loop selects how main.yml feeds a task file its values.
+type Loop string
+
+const (
+	// LoopList loops over a list of items named after the module. It is
+	// the default.
+	LoopList Loop = "list"
+	// LoopDict loops over a dict through dict2items; the item keys fill
+	// the module's key option, such as name or path.
+	LoopDict Loop = "dict"
+	// LoopSingle includes the task file once, reading flat variables
+	// named <basename>_<option>.
+	LoopSingle Loop = "single"
+)
+
+// Loops lists the supported loop strategies.
+var Loops = []Loop{LoopList, LoopDict, LoopSingle}
+
+// LabelAuto picks the loop label from the first key option a module has.
+const LabelAuto = "auto"
+
+// ParseLoop validates a loop strategy name. The empty string is LoopList.
+func ParseLoop(s string) (Loop, error) {
+	if s == "" {
+		return LoopList, nil
+	}
+	for _, loop := range Loops {
+		if string(loop) == s {
+			return loop, nil
+		}
+	}
+	return "", fmt.Errorf("unknown loop strategy %q (want one of %v)", s, Loops)
+}
+
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateLoopVar checks a loop_var setting. "{module}" stands for the
+// module basename.
+func ValidateLoopVar(loopVar string) error {
+	if loopVar == "" {
+		return nil
+	}
+	if !variablePattern.MatchString(strings.ReplaceAll(loopVar, "{module}", "x")) {
+		return fmt.Errorf("invalid loop variable %q", loopVar)
+	}
+	return nil
+}
+
+// itemVars knows how a task file refers to the values of one module.
+type itemVars struct {
+	basename string
+	loop     Loop
+	loopVar  string
+}
+
+func newItemVars(basename string, opts Options) itemVars {
+	loop, _ := ParseLoop(string(opts.Loop))
+	return itemVars{
+		basename: basename,
+		loop:     loop,
+		loopVar:  strings.ReplaceAll(opts.LoopVar, "{module}", basename),
+	}
+}
+
+// root is the loop variable, or "" without a loop.
+func (v itemVars) root() string {
+	switch {
+	case v.loop == LoopSingle:
+		return ""
+	case v.loopVar != "":
+		return v.loopVar
+	}
+	return "item"
+}
+
+// item is the mapping holding the option values, or "" for flat variables.
+func (v itemVars) item() string {
+	if v.loop == LoopDict {
+		return v.root() + ".value"
+	}
+	return v.root()
+}
+
+// ref is the expression holding the value of an option.
+func (v itemVars) ref(name string) string {
+	if v.loop == LoopSingle {
+		return v.basename + "_" + name
+	}
+	return v.item() + "." + name
+}
+
+// key is the dict key of the item, or "" unless looping over a dict.
+func (v itemVars) key() string {
+	if v.loop != LoopDict {
+		return ""
+	}
+	return v.root() + ".key"
+}
@@ -0,0 +1,167 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"atcg/internal/atcg/mocks"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestGenerateTask_LoopDict(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	got, err := GenerateTask("ansible.windows.win_service", doc, Options{Loop: LoopDict, LoopVar: "{module}_item", Guards: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Validate win_service
+  ansible.builtin.assert:
+    that:
+      - "win_service_item.value is mapping"
+      - "win_service_item.value.keys() | difference(['name', 'state']) | length == 0"
+      - "win_service_item.value.name is not defined or (win_service_item.value.name is string or win_service_item.value.name is number or win_service_item.value.name is boolean)"
+    fail_msg: "win_service item {{ win_service_index | default('?') }} is invalid, see the failed assertion"
+    quiet: true
+  tags: [win_service]
+
+- name: Configure win_service
+  ansible.windows.win_service:
+    name: "{{ win_service_item.value.name | default(win_service_item.key) }}"
+    state: "{{ win_service_item.value.state | default('present') }}"
+  tags: [win_service]
+`
+	if got != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestGenerateTask_LoopSingle(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	got, err := GenerateTask("ansible.windows.win_service", doc, Options{Loop: LoopSingle, Guards: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Validate win_service
+  ansible.builtin.assert:
+    that:
+      - "win_service_name is defined"
+      - "win_service_name is not defined or (win_service_name is string or win_service_name is number or win_service_name is boolean)"
+    fail_msg: "win_service variables are invalid, see the failed assertion"
+    quiet: true
+  tags: [win_service]
+
+- name: Configure win_service
+  ansible.windows.win_service:
+    name: "{{ win_service_name | mandatory('win_service requires win_service_name') }}"
+    state: "{{ win_service_state | default('present') }}"
+  tags: [win_service]
+`
+	if got != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestRenderMain_Loops(t *testing.T) {
+	modules := []Module{{Name: "ansible.windows.win_service", Basename: "win_service", Label: "{{ win_service_item.key }}"}}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{
+			name: "Dict with loop_var",
+			opts: Options{Loop: LoopDict, LoopVar: "{module}_item"},
+			expected: `  when: win_service is defined
+  loop: "{{ win_service | dict2items }}"
+  loop_control:
+    loop_var: win_service_item
+    label: "{{ win_service_item.key }}"
+  tags: win_service
+`,
+		},
+		{
+			name: "Single",
+			opts: Options{Loop: LoopSingle, LoopVar: "ignored"},
+			expected: `      tags: win_service
+  when: win_service_enabled | default(false) | bool
+  tags: win_service
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderMain(modules, tt.opts)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.HasSuffix(got, tt.expected) {
+				t.Errorf("unexpected main.yml:\n%s\nexpected to end with:\n%s", got, tt.expected)
+			}
+			var parsed interface{}
+			if err := yaml.Unmarshal([]byte(got), &parsed); err != nil {
+				t.Errorf("main.yml is not valid YAML: %v", err)
+			}
+		})
+	}
+
+	if _, err := RenderMain(modules, Options{Loop: "tree"}); err == nil || !strings.Contains(err.Error(), `unknown loop strategy "tree"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRenderModule_Label(t *testing.T) {
+	mockExecutor := &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			return []byte(`{"ansible.builtin.file": {"doc": {"options": {"path": {}, "state": {}}}}}`), nil
+		},
+	}
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{opts: Options{}, expected: ""},
+		{opts: Options{Label: LabelAuto}, expected: "{{ item.path | default('file') }}"},
+		{opts: Options{Label: "state", LoopVar: "f"}, expected: "{{ f.state | default('file') }}"},
+		{opts: Options{Loop: LoopSingle, Label: LabelAuto}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		_, details, err := RenderModule("ansible.builtin.file", mockExecutor, tt.opts)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if details.Label != tt.expected {
+			t.Errorf("options %+v: got label %q, want %q", tt.opts, details.Label, tt.expected)
+		}
+	}
+}
+
+func TestValidateLoopVar(t *testing.T) {
+	for _, valid := range []string{"", "entry", "{module}_item"} {
+		if err := ValidateLoopVar(valid); err != nil {
+			t.Errorf("%q: unexpected error %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"1x", "a-b", "{mod}"} {
+		if err := ValidateLoopVar(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
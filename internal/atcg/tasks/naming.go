@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Naming schemes accepted by Options.Naming besides custom templates.
+const (
+	// NamingShort names files and variables after the module basename,
+	// such as user. It is the default.
+	NamingShort = "short"
+	// NamingQualified includes the collection, such as community_general_user.
+	NamingQualified = "qualified"
+)
+
+// Collision strategies accepted by Options.Collisions.
+const (
+	// CollisionsError fails when two modules get the same name. It is the
+	// default.
+	CollisionsError = "error"
+	// CollisionsQualify names colliding modules with NamingQualified.
+	CollisionsQualify = "qualify"
+)
+
+var (
+	namePlaceholder = regexp.MustCompile(`\{[a-z]+\}`)
+	nameUnsafe      = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+	nameRepeats     = regexp.MustCompile(`_{2,}`)
+)
+
+// namingTemplate expands the scheme shortcuts into a template.
+func namingTemplate(naming string) string {
+	switch naming {
+	case "", NamingShort:
+		return "{module}"
+	case NamingQualified:
+		return "{namespace}_{collection}_{module}"
+	}
+	return naming
+}
+
+// ValidateNaming checks a naming scheme and collision strategy. Templates
+// may use {namespace}, {collection} and {module}.
+func ValidateNaming(naming, prefix, collisions string) error {
+	for _, placeholder := range namePlaceholder.FindAllString(namingTemplate(naming), -1) {
+		switch placeholder {
+		case "{namespace}", "{collection}", "{module}":
+		default:
+			return fmt.Errorf("naming template %q: unknown placeholder %s", naming, placeholder)
+		}
+	}
+	if !strings.Contains(namingTemplate(naming), "{module}") {
+		return fmt.Errorf("naming template %q must contain {module}", naming)
+	}
+	if prefix != "" && !variablePattern.MatchString(prefix) {
+		return fmt.Errorf("invalid name prefix %q", prefix)
+	}
+	switch collisions {
+	case "", CollisionsError, CollisionsQualify:
+	default:
+		return fmt.Errorf("unknown collision strategy %q (want %s or %s)", collisions, CollisionsError, CollisionsQualify)
+	}
+	return nil
+}
+
+// moduleName applies a naming template and prefix to a module, joining the
+// prefix with an underscore. The result is usable as a file name, variable
+// name and tag.
+func moduleName(module, naming, prefix string) string {
+	parts := strings.Split(module, ".")
+	basename := parts[len(parts)-1]
+	namespace, collection := "", ""
+	if len(parts) >= 3 {
+		namespace, collection = parts[0], strings.Join(parts[1:len(parts)-1], "_")
+	}
+
+	name := strings.NewReplacer(
+		"{namespace}", namespace,
+		"{collection}", collection,
+		"{module}", basename,
+	).Replace(namingTemplate(naming))
+	name = strings.Trim(nameUnsafe.ReplaceAllString(name, "_"), "_")
+	name = nameRepeats.ReplaceAllString(name, "_")
+
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
+
+// Name returns the name used for the files, variables and tags of module.
+func (o Options) Name(module string) string {
+	if name, ok := o.Names[module]; ok {
+		return name
+	}
+	return moduleName(module, o.Naming, o.Prefix)
+}
+
+// WithNames returns a copy of o with the names of modules resolved up
+// front. Two modules sharing a name are an error, unless Collisions is
+// CollisionsQualify and qualifying them tells them apart.
+func (o Options) WithNames(modules []string) (Options, error) {
+	names := make(map[string]string, len(modules))
+	owners := make(map[string][]string)
+	for _, module := range modules {
+		module = strings.TrimSpace(module)
+		if _, ok := names[module]; ok {
+			continue
+		}
+		name := moduleName(module, o.Naming, o.Prefix)
+		names[module] = name
+		owners[name] = append(owners[name], module)
+	}
+
+	if o.Collisions == CollisionsQualify {
+		for _, shared := range owners {
+			if len(shared) < 2 {
+				continue
+			}
+			for _, module := range shared {
+				names[module] = moduleName(module, NamingQualified, o.Prefix)
+			}
+		}
+		owners = make(map[string][]string)
+		for module, name := range names {
+			owners[name] = append(owners[name], module)
+		}
+	}
+
+	var collisions []string
+	for name, shared := range owners {
+		if len(shared) > 1 {
+			sort.Strings(shared)
+			if len(shared) == 2 {
+				collisions = append(collisions, fmt.Sprintf("%s and %s both map to %q", shared[0], shared[1], name))
+			} else {
+				collisions = append(collisions, fmt.Sprintf("%s all map to %q", strings.Join(shared, ", "), name))
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return o, fmt.Errorf("module name collision: %s; choose another naming scheme or qualify colliding modules", strings.Join(collisions, "; "))
+	}
+
+	o.Names = names
+	return o, nil
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestModuleName(t *testing.T) {
+	tests := []struct {
+		module, naming, prefix, expected string
+	}{
+		{module: "ansible.builtin.user", expected: "user"},
+		{module: "community.general.user", naming: NamingQualified, expected: "community_general_user"},
+		{module: "community.general.user", naming: "{collection}_{module}", prefix: "web_", expected: "web_general_user"},
+		{module: "ansible.builtin.user", prefix: "web", expected: "web_user"},
+		{module: "user", naming: NamingQualified, expected: "user"},
+		{module: "my-ns.col.mod", naming: "{namespace}__{module}", expected: "my_ns_mod"},
+	}
+
+	for _, tt := range tests {
+		if got := moduleName(tt.module, tt.naming, tt.prefix); got != tt.expected {
+			t.Errorf("moduleName(%q, %q, %q) = %q, want %q", tt.module, tt.naming, tt.prefix, got, tt.expected)
+		}
+	}
+}
+
+func TestOptions_WithNames(t *testing.T) {
+	modules := []string{"ansible.builtin.user", "community.general.user", "ansible.builtin.copy", "ansible.builtin.copy"}
+
+	_, err := Options{}.WithNames(modules)
+	if err == nil || !strings.Contains(err.Error(), `ansible.builtin.user and community.general.user both map to "user"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	opts, err := Options{Collisions: CollisionsQualify, Prefix: "r_"}.WithNames(modules)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := map[string]string{
+		"ansible.builtin.user":   "r_ansible_builtin_user",
+		"community.general.user": "r_community_general_user",
+		"ansible.builtin.copy":   "r_copy",
+	}
+	for module, name := range expected {
+		if got := opts.Name(module); got != name {
+			t.Errorf("Name(%q) = %q, want %q", module, got, name)
+		}
+	}
+}
+
+func TestOptions_WithNames_StillColliding(t *testing.T) {
+	_, err := Options{Naming: "{module}", Collisions: CollisionsQualify}.WithNames([]string{"a.b_c.user", "a_b.c.user"})
+	if err == nil || !strings.Contains(err.Error(), `a.b_c.user and a_b.c.user both map to "a_b_c_user"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestOptions_WithNames_ThreeColliding(t *testing.T) {
+	_, err := Options{}.WithNames([]string{"a.b.user", "c.d.user", "e.f.user"})
+	if err == nil || !strings.Contains(err.Error(), `a.b.user, c.d.user, e.f.user all map to "user"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestValidateNaming(t *testing.T) {
+	tests := []struct {
+		naming, prefix, collisions, wantErr string
+	}{
+		{naming: "", prefix: "", collisions: ""},
+		{naming: "{namespace}_{module}", prefix: "role_", collisions: CollisionsQualify},
+		{naming: "{name}", wantErr: "unknown placeholder {name}"},
+		{naming: "{collection}", wantErr: "must contain {module}"},
+		{prefix: "my-role", wantErr: `invalid name prefix "my-role"`},
+		{collisions: "rename", wantErr: `unknown collision strategy "rename"`},
+	}
+
+	for _, tt := range tests {
+		err := ValidateNaming(tt.naming, tt.prefix, tt.collisions)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("ValidateNaming(%q, %q, %q) = %v, want %q", tt.naming, tt.prefix, tt.collisions, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGenerateTask_Naming(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{
+		"name":  {Required: true, Type: "str"},
+		"state": {Default: "present"},
+	}}
+	opts, err := Options{Naming: NamingQualified}.WithNames([]string{"community.general.user"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, err := GenerateTask("community.general.user", doc, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(got, "---\n# Generated by atcg. Edits are lost when it runs again.\n- name: Configure community_general_user\n") || !strings.HasSuffix(got, "  tags: [community_general_user]\n") {
+		t.Errorf("unexpected task:\n%s", got)
+	}
+}
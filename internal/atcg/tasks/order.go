@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// Order selects the order options are written in.
+type Order string
+
+const (
+	// OrderAlphabetical sorts options by name. It is the default.
+	OrderAlphabetical Order = "alphabetical"
+	// OrderDocumented keeps the order of the module documentation.
+	OrderDocumented Order = "documented"
+	// OrderRequiredFirst puts required options first, each part sorted by name.
+	OrderRequiredFirst Order = "required-first"
+	// OrderGrouped writes options in the sections given by Options.Groups.
+	OrderGrouped Order = "grouped"
+)
+
+// Orders lists the supported orders.
+var Orders = []Order{OrderAlphabetical, OrderDocumented, OrderRequiredFirst, OrderGrouped}
+
+// ParseOrder validates an order name. The empty string is OrderAlphabetical.
+func ParseOrder(s string) (Order, error) {
+	if s == "" {
+		return OrderAlphabetical, nil
+	}
+	for _, order := range Orders {
+		if string(order) == s {
+			return order, nil
+		}
+	}
+	return "", fmt.Errorf("unknown option order %q (want one of %v)", s, Orders)
+}
+
+// Group is a section of options for OrderGrouped. Options holds option
+// names or path.Match patterns such as "*password*".
+type Group struct {
+	Name    string
+	Options []string
+}
+
+// otherGroup names the section of options that match no group.
+const otherGroup = "other"
+
+// field is an option as it is written to a task file. Section is set on
+// the first option of a section.
+type field struct {
+	Name    string
+	Option  atcgModules.ModuleOption
+	Section string
+}
+
+// orderFields returns the options of doc in the order opts asks for.
+func orderFields(doc *atcgModules.ModuleDoc, opts Options) ([]field, error) {
+	order, err := ParseOrder(string(opts.Order))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	switch order {
+	case OrderAlphabetical:
+		names = atcgModules.OptionNames(doc.Options)
+	case OrderDocumented:
+		names = doc.OptionNames()
+	case OrderRequiredFirst:
+		names = atcgModules.OptionNames(doc.Options)
+		sort.SliceStable(names, func(i, j int) bool {
+			return doc.Options[names[i]].Required && !doc.Options[names[j]].Required
+		})
+	case OrderGrouped:
+		return groupFields(doc, opts.Groups)
+	}
+
+	fields := make([]field, len(names))
+	for i, name := range names {
+		fields[i] = field{Name: name, Option: doc.Options[name]}
+	}
+	return fields, nil
+}
+
+// groupFields splits the options into the configured groups. An option goes
+// to the first group that matches it; within a group options follow the
+// group's list, then the name. Unmatched options end up in a final "other"
+// section, which has no heading when there are no groups at all.
+func groupFields(doc *atcgModules.ModuleDoc, groups []Group) ([]field, error) {
+	type rank struct{ group, pattern int }
+	ranks := make(map[string]rank, len(doc.Options))
+
+	for name := range doc.Options {
+		ranks[name] = rank{group: len(groups)}
+	match:
+		for g, group := range groups {
+			for p, pattern := range group.Options {
+				matched, err := path.Match(pattern, name)
+				if err != nil {
+					return nil, fmt.Errorf("group %q: invalid pattern %q: %w", group.Name, pattern, err)
+				}
+				if matched {
+					ranks[name] = rank{group: g, pattern: p}
+					break match
+				}
+			}
+		}
+	}
+
+	names := atcgModules.OptionNames(doc.Options)
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := ranks[names[i]], ranks[names[j]]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		return a.pattern < b.pattern
+	})
+
+	fields := make([]field, len(names))
+	for i, name := range names {
+		fields[i] = field{Name: name, Option: doc.Options[name]}
+		g := ranks[name].group
+		if i > 0 && ranks[names[i-1]].group == g {
+			continue
+		}
+		if g < len(groups) {
+			fields[i].Section = groups[g].Name
+		} else if len(groups) > 0 {
+			fields[i].Section = otherGroup
+		}
+	}
+	return fields, nil
+}
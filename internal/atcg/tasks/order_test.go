@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func orderTestDoc() *atcgModules.ModuleDoc {
+	return &atcgModules.ModuleDoc{
+		Options: map[string]atcgModules.ModuleOption{
+			"name":           {Required: true},
+			"hostname":       {Required: true},
+			"password":       {},
+			"admin_password": {},
+			"port":           {},
+			"state":          {},
+		},
+		OptionOrder: []string{"hostname", "port", "name", "state", "password", "admin_password"},
+	}
+}
+
+func TestOrderFields(t *testing.T) {
+	tests := []struct {
+		order    Order
+		expected []string
+	}{
+		{order: "", expected: []string{"admin_password", "hostname", "name", "password", "port", "state"}},
+		{order: OrderDocumented, expected: []string{"hostname", "port", "name", "state", "password", "admin_password"}},
+		{order: OrderRequiredFirst, expected: []string{"hostname", "name", "admin_password", "password", "port", "state"}},
+		{order: OrderGrouped, expected: []string{"admin_password", "hostname", "name", "password", "port", "state"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			fields, err := orderFields(orderTestDoc(), Options{Order: tt.order})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			var names []string
+			for _, field := range fields {
+				names = append(names, field.Name)
+				if field.Section != "" {
+					t.Errorf("unexpected section %q on %s", field.Section, field.Name)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("got %v, want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOrderFields_Grouped(t *testing.T) {
+	opts := Options{
+		Order: OrderGrouped,
+		Groups: []Group{
+			{Name: "identity", Options: []string{"name", "*password*"}},
+			{Name: "connection", Options: []string{"hostname", "port"}},
+		},
+	}
+
+	fields, err := orderFields(orderTestDoc(), opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var got []string
+	for _, field := range fields {
+		entry := field.Name
+		if field.Section != "" {
+			entry = field.Section + ":" + entry
+		}
+		got = append(got, entry)
+	}
+	expected := []string{"identity:name", "admin_password", "password", "connection:hostname", "port", "other:state"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+}
+
+func TestOrderFields_Errors(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"name": {}}}
+	if _, err := orderFields(doc, Options{Order: "random"}); err == nil || !strings.Contains(err.Error(), `unknown option order "random"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	opts := Options{Order: OrderGrouped, Groups: []Group{{Name: "bad", Options: []string{"["}}}}
+	if _, err := orderFields(doc, opts); err == nil || !strings.Contains(err.Error(), `invalid pattern "["`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGenerateTask_GroupedSections(t *testing.T) {
+	opts := Options{
+		Order:  OrderGrouped,
+		Groups: []Group{{Name: "connection", Options: []string{"hostname", "port"}}},
+	}
+
+	got, err := GenerateTask("community.vmware.vmware_guest", orderTestDoc(), opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Configure vmware_guest
+  community.vmware.vmware_guest:
+    # --- connection ---
+    hostname: "{{ item.hostname }}"
+    port: "{{ item.port | default(omit) }}"
+    # --- other ---
+    admin_password: "{{ item.admin_password | default(omit) }}"
+    name: "{{ item.name }}"
+    password: "{{ item.password | default(omit) }}"
+    state: "{{ item.state | default(omit) }}"
+  tags: [vmware_guest]
+`
+	if got != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", got, expected)
+	}
+}
@@ -3,7 +3,6 @@ package tasks
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	atcgModules "atcg/internal/atcg/modules"
@@ -13,28 +12,53 @@ import (
 // ParseAndGenerateTask parses module documentation and generates task YAML.
 var generateTaskFunc = GenerateTask
 
-func ParseAndGenerateTask(module string, executor atcgModules.CommandExecutor) (string, error) {
+func ParseAndGenerateTask(module string, executor atcgModules.CommandExecutor, opts Options) (string, error) {
+	task, _, err := RenderModule(module, executor, opts)
+	return task, err
+}
+
+// RenderModule parses module documentation and returns the task YAML along
+// with the module's entry in main.yml.
+func RenderModule(module string, executor atcgModules.CommandExecutor, opts Options) (string, *Module, error) {
 	module = strings.TrimSpace(module)
 
 	doc, err := atcgModules.ParseModuleDoc(executor, module)
 	if err != nil {
-		return "", fmt.Errorf("error fetching documentation for module %s: %w", module, err)
+		return "", nil, fmt.Errorf("error fetching documentation for module %s: %w", module, err)
 	}
 
-	task, err := generateTaskFunc(module, doc)
+	task, err := generateTaskFunc(module, doc, opts)
 	if err != nil {
-		return "", fmt.Errorf("error generating task for module %s: %w", module, err)
+		return "", nil, fmt.Errorf("error generating task for module %s: %w", module, err)
 	}
 
-	return task, nil
+	details := &Module{Name: module, Basename: opts.Name(module), Path: opts.TaskFile(module)}
+	vars := newItemVars(details.Basename, opts)
+	sensitive := opts.NoLog && len(SensitiveOptions(doc, opts.SensitivePatterns)) > 0
+	if vars.root() != "" && (vars.loop == LoopDict || opts.Label != "" || sensitive) {
+		details.Label = safeLabel(vars, doc, opts)
+	}
+
+	return task, details, nil
 }
 
 // WriteTaskToFile writes the task YAML to a file.
 var WriteFile = os.WriteFile // Global variable for dependency injection
 
 func WriteTaskToFile(task string, module string, outputDir string) (string, error) {
-	basename := utils.Basename(module)
-	outputFile := filepath.Join(outputDir, basename+".yml")
+	return writeTaskFile(task, utils.Basename(module)+".yml", outputDir)
+}
+
+// writeTaskFile writes the task YAML to the slash-separated path below
+// outputDir, creating its directories.
+func writeTaskFile(task string, name string, outputDir string) (string, error) {
+	outputFile, err := OutputPath(outputDir, name)
+	if err != nil {
+		return "", err
+	}
+	if err := makeParentDir(outputDir, name); err != nil {
+		return "", err
+	}
 
 	if err := WriteFile(outputFile, []byte(task), 0644); err != nil {
 		return "", fmt.Errorf("error writing task to file %s: %w", outputFile, err)
@@ -44,21 +68,18 @@ func WriteTaskToFile(task string, module string, outputDir string) (string, erro
 }
 
 // ProcessModule processes a single module by parsing documentation, generating tasks, and writing to a file.
-func ProcessModule(module string, outputDir string, executor atcgModules.CommandExecutor) (*Module, error) {
-	task, err := ParseAndGenerateTask(module, executor)
+func ProcessModule(module string, outputDir string, executor atcgModules.CommandExecutor, opts Options) (*Module, error) {
+	task, details, err := RenderModule(module, executor, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	outputFile, err := WriteTaskToFile(task, module, outputDir)
+	outputFile, err := writeTaskFile(task, details.Path, outputDir)
 	if err != nil {
 		return nil, err
 	}
 
 	fmt.Printf("Generated task for %s: %s\n", module, outputFile)
 
-	return &Module{
-		Name:     module,
-		Basename: utils.Basename(module),
-	}, nil
+	return details, nil
 }
@@ -19,12 +19,13 @@ func TestParseAndGenerateTask_Success(t *testing.T) {
 		},
 	}
 
-	task, err := ParseAndGenerateTask("ansible.builtin.debug", mockExecutor)
+	task, err := ParseAndGenerateTask("ansible.builtin.debug", mockExecutor, Options{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 
 	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
 - name: Configure debug
   ansible.builtin.debug:
     msg: "{{ item.msg | default('Hello, World!') }}"
@@ -44,7 +45,7 @@ func TestParseAndGenerateTask_ParseError(t *testing.T) {
 	}
 
 	// Call ParseAndGenerateTask
-	_, err := ParseAndGenerateTask("ansible.builtin.debug", mockExecutor)
+	_, err := ParseAndGenerateTask("ansible.builtin.debug", mockExecutor, Options{})
 	if err == nil {
 		t.Fatal("expected an error, got nil")
 	}
@@ -88,7 +89,7 @@ func TestProcessModule_Success(t *testing.T) {
 	}
 
 	outputDir := t.TempDir()
-	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor)
+	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor, Options{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -111,7 +112,7 @@ func TestProcessModule_ParseError(t *testing.T) {
 	}
 
 	outputDir := t.TempDir()
-	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor)
+	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor, Options{})
 
 	if result != nil {
 		t.Fatalf("expected result to be nil, got %v", result)
@@ -139,7 +140,7 @@ func TestProcessModule_WriteError(t *testing.T) {
 	}
 
 	outputDir := t.TempDir()
-	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor)
+	result, err := ProcessModule("ansible.builtin.debug", outputDir, mockExecutor, Options{})
 
 	if result != nil {
 		t.Fatalf("expected result to be nil, got %v", result)
@@ -156,7 +157,7 @@ func TestParseAndGenerateTask_GenerateTaskError(t *testing.T) {
 	defer func() { generateTaskFunc = originalGenerateTask }()
 
 	// Mock GenerateTask to return an error
-	generateTaskFunc = func(module string, doc *atcgModules.ModuleDoc) (string, error) {
+	generateTaskFunc = func(module string, doc *atcgModules.ModuleDoc, opts Options) (string, error) {
 		return "", fmt.Errorf("mock generate task error")
 	}
 
@@ -168,7 +169,7 @@ func TestParseAndGenerateTask_GenerateTaskError(t *testing.T) {
 	}
 
 	module := "ansible.builtin.debug"
-	output, err := ParseAndGenerateTask(module, mockExecutor)
+	output, err := ParseAndGenerateTask(module, mockExecutor, Options{})
 
 	// Validate that an error occurred
 	if err == nil {
@@ -0,0 +1,91 @@
+package tasks
+
+import (
+	"path"
+	"strings"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+// DefaultSensitivePatterns are the option name patterns treated as secrets
+// when Options.SensitivePatterns is empty.
+var DefaultSensitivePatterns = []string{
+	"*password*", "*passwd*", "*passphrase*", "*secret*", "*token*",
+	"*api_key*", "*apikey*", "*private_key*",
+}
+
+// NoLogVar is the variable that turns no_log off for every generated task.
+// A per-module <basename>_no_log variable takes precedence.
+const NoLogVar = "atcg_no_log"
+
+// labelKeys are the options, in order of preference, used to label loop
+// items of modules with sensitive options.
+var labelKeys = []string{"name", "path", "dest", "username", "user", "key", "src", "id"}
+
+// isSensitive reports whether an option holds a secret: the documentation
+// marks it no_log, or its name or an alias matches one of the patterns.
+// Booleans and options restricted to choices hold no secret whatever
+// their name, as in update_password.
+func isSensitive(name string, option atcgModules.ModuleOption, patterns []string) bool {
+	if option.NoLog {
+		return true
+	}
+	if option.Type == "bool" || len(option.Choices) > 0 {
+		return false
+	}
+	if len(patterns) == 0 {
+		patterns = DefaultSensitivePatterns
+	}
+
+	for _, candidate := range append([]string{name}, option.Aliases...) {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(candidate)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SensitiveOptions returns the sorted names of the options of doc that hold secrets.
+func SensitiveOptions(doc *atcgModules.ModuleDoc, patterns []string) []string {
+	var names []string
+	for _, name := range atcgModules.OptionNames(doc.Options) {
+		if isSensitive(name, doc.Options[name], patterns) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// noLogValue renders the no_log keyword value of a task.
+func noLogValue(basename string) string {
+	return "{{ " + basename + "_no_log | default(" + NoLogVar + " | default(true)) }}"
+}
+
+// keyOption returns the option naming an item: preferred if the module has
+// it, else the first of labelKeys. Sensitive options never qualify.
+func keyOption(doc *atcgModules.ModuleDoc, preferred string, patterns []string) string {
+	candidates := labelKeys
+	if preferred != "" && preferred != LabelAuto {
+		candidates = append([]string{preferred}, labelKeys...)
+	}
+	for _, key := range candidates {
+		if option, ok := doc.Options[key]; ok && !isSensitive(key, option, patterns) {
+			return key
+		}
+	}
+	return ""
+}
+
+// safeLabel returns a loop label naming an item by its dict key or a
+// non-sensitive key option, or the basename when the module has neither.
+func safeLabel(vars itemVars, doc *atcgModules.ModuleDoc, opts Options) string {
+	if key := vars.key(); key != "" {
+		return "{{ " + key + " }}"
+	}
+	if key := keyOption(doc, opts.Label, opts.SensitivePatterns); key != "" {
+		return "{{ " + vars.ref(key) + " | default('" + vars.basename + "') }}"
+	}
+	return vars.basename
+}
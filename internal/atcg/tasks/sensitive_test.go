@@ -0,0 +1,95 @@
+package tasks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"atcg/internal/atcg/mocks"
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestSensitiveOptions(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{
+		Options: map[string]atcgModules.ModuleOption{
+			"name":             {Type: "str"},
+			"password":         {Type: "str"},
+			"update_password":  {Type: "str", Choices: []interface{}{"always", "on_create"}},
+			"password_expired": {Type: "bool"},
+			"url_password":     {Type: "str", Aliases: []string{"x"}},
+			"pin":              {Type: "str", NoLog: true},
+			"login":            {Type: "str", Aliases: []string{"API_TOKEN"}},
+		},
+	}
+
+	expected := []string{"login", "password", "pin", "url_password"}
+	if got := SensitiveOptions(doc, nil); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, want %v", got, expected)
+	}
+
+	expected = []string{"name", "pin"}
+	if got := SensitiveOptions(doc, []string{"NAME"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v with custom patterns, want %v", got, expected)
+	}
+}
+
+func TestRenderModule_NoLog(t *testing.T) {
+	mockExecutor := &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			return []byte(`{"ansible.windows.win_user": {"doc": {"options": {"name": {"required": true}, "password": {"type": "str"}}}}}`), nil
+		},
+	}
+
+	task, details, err := RenderModule("ansible.windows.win_user", mockExecutor, Options{NoLog: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `---
+# Generated by atcg. Edits are lost when it runs again.
+- name: Configure win_user
+  ansible.windows.win_user:
+    name: "{{ item.name }}"
+    password: "{{ item.password | default(omit) }}"
+  no_log: "{{ win_user_no_log | default(atcg_no_log | default(true)) }}"
+  tags: [win_user]
+`
+	if task != expected {
+		t.Errorf("unexpected task:\n%s\nexpected:\n%s", task, expected)
+	}
+
+	if details.Label != "{{ item.name | default('win_user') }}" {
+		t.Errorf("unexpected label %q", details.Label)
+	}
+
+	main, err := RenderMain([]Module{*details}, Options{NoLog: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(main, "  loop_control:\n    label: \"{{ item.name | default('win_user') }}\"\n") {
+		t.Errorf("expected a loop label, got:\n%s", main)
+	}
+}
+
+func TestRenderModule_NoLogWithoutSecrets(t *testing.T) {
+	mockExecutor := &mocks.MockExecutor{
+		MockExecute: func(command string, args ...string) ([]byte, error) {
+			return []byte(`{"ansible.builtin.debug": {"doc": {"options": {"msg": {}}}}}`), nil
+		},
+	}
+
+	task, details, err := RenderModule("ansible.builtin.debug", mockExecutor, Options{NoLog: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Contains(task, "no_log") || details.Label != "" {
+		t.Errorf("expected no no_log handling, got label %q and task:\n%s", details.Label, task)
+	}
+}
+
+func TestSafeLabel_NoKey(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{Options: map[string]atcgModules.ModuleOption{"token": {}}}
+	if got := safeLabel(newItemVars("uri", Options{}), doc, Options{}); got != "uri" {
+		t.Errorf("got %q, want the basename", got)
+	}
+}
@@ -0,0 +1,206 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InvalidYAMLError reports generated output that does not parse, or does not
+// have the shape atcg meant to produce.
+type InvalidYAMLError struct {
+	File    string
+	Line    int
+	Message string
+	// Source is the offending line, if known.
+	Source string
+}
+
+func (e *InvalidYAMLError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s\n    %d | %s", e.File, e.Line, e.Message, e.Line, e.Source)
+}
+
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// newYAMLError builds an InvalidYAMLError pointing at line of content.
+func newYAMLError(file, content string, line int, format string, args ...interface{}) *InvalidYAMLError {
+	err := &InvalidYAMLError{File: file, Line: line, Message: fmt.Sprintf(format, args...)}
+	lines := strings.Split(content, "\n")
+	if line > 0 && line <= len(lines) {
+		err.Source = lines[line-1]
+	}
+	return err
+}
+
+// failingLine returns the line the parser fails on. yaml.v3 reports the
+// line of the enclosing block instead, such as the key of a mapping holding
+// a badly quoted value, so the first line whose prefix of content no longer
+// parses is taken; reported is kept when there is none.
+func failingLine(content string, reported int) int {
+	lines := strings.SplitAfter(content, "\n")
+	prefix := ""
+	for i, line := range lines {
+		prefix += line
+		var doc yaml.Node
+		decoder := yaml.NewDecoder(strings.NewReader(prefix))
+		for {
+			err := decoder.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return i + 1
+			}
+		}
+	}
+	return reported
+}
+
+// parseYAML parses content as a single YAML document and rejects duplicate
+// mapping keys. It returns the root node, which is nil for an empty document.
+func parseYAML(file, content string) (*yaml.Node, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+
+	var doc yaml.Node
+	if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			return nil, newYAMLError(file, content, failingLine(content, line), "%s", match[2])
+		}
+		return nil, newYAMLError(file, content, 0, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+
+	var extra yaml.Node
+	if err := decoder.Decode(&extra); err == nil {
+		return nil, newYAMLError(file, content, extra.Line, "expected a single YAML document")
+	} else if !errors.Is(err, io.EOF) {
+		return nil, newYAMLError(file, content, 0, "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if err := checkDuplicateKeys(file, content, root); err != nil {
+		return nil, err
+	}
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return nil, nil
+	}
+	return root, nil
+}
+
+// checkDuplicateKeys walks node and fails on the first key defined twice in
+// one mapping.
+func checkDuplicateKeys(file, content string, node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		seen := make(map[string]int, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if first, ok := seen[key.Value]; ok {
+				return newYAMLError(file, content, key.Line, "duplicate key %q, first defined on line %d", key.Value, first)
+			}
+			seen[key.Value] = key.Line
+		}
+	}
+	for _, child := range node.Content {
+		if err := checkDuplicateKeys(file, content, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// taskList checks that root is a list of named tasks and returns them.
+func taskList(file, content string, root *yaml.Node) ([]*yaml.Node, error) {
+	if root == nil {
+		return nil, nil
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, newYAMLError(file, content, root.Line, "expected a list of tasks")
+	}
+	for _, task := range root.Content {
+		if task.Kind != yaml.MappingNode {
+			return nil, newYAMLError(file, content, task.Line, "expected a task mapping")
+		}
+		if name := mappingValue(task, "name"); name == nil || name.Kind != yaml.ScalarNode || name.Value == "" {
+			return nil, newYAMLError(file, content, task.Line, "task has no name")
+		}
+	}
+	return root.Content, nil
+}
+
+// ValidateTask checks a generated task file: a single document holding a
+// list of named tasks, exactly one of which calls module with a mapping of
+// options, and no duplicate keys.
+func ValidateTask(file, module, content string) error {
+	root, err := parseYAML(file, content)
+	if err != nil {
+		return err
+	}
+	tasks, err := taskList(file, content, root)
+	if err != nil {
+		return err
+	}
+
+	var calls []*yaml.Node
+	for _, task := range tasks {
+		if args := mappingValue(task, module); args != nil {
+			calls = append(calls, task)
+			if args.Kind != yaml.MappingNode {
+				return newYAMLError(file, content, args.Line, "expected the options of %s to be a mapping", module)
+			}
+		}
+	}
+	switch len(calls) {
+	case 0:
+		return newYAMLError(file, content, 0, "no task calls %s", module)
+	case 1:
+		return nil
+	}
+	return newYAMLError(file, content, calls[1].Line, "%s is called more than once", module)
+}
+
+// ValidateMain checks a generated main.yml: a single document holding a
+// list of named tasks that each include a file, and no duplicate keys.
+func ValidateMain(file, content string) error {
+	root, err := parseYAML(file, content)
+	if err != nil {
+		return err
+	}
+	tasks, err := taskList(file, content, root)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		include := mappingValue(task, "ansible.builtin.include_tasks")
+		if include == nil || include.Kind != yaml.MappingNode {
+			return newYAMLError(file, content, task.Line, "expected an ansible.builtin.include_tasks mapping")
+		}
+		if target := mappingValue(include, "file"); target == nil || target.Value == "" {
+			return newYAMLError(file, content, include.Line, "include_tasks has no file")
+		}
+	}
+	return nil
+}
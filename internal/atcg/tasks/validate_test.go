@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	atcgModules "atcg/internal/atcg/modules"
+)
+
+func TestValidateTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Valid",
+			content: "---\n- name: Configure ping\n  ansible.builtin.ping:\n    data: x\n",
+		},
+		{
+			name:    "Syntax error",
+			content: "---\n- name: Configure ping\n  ansible.builtin.ping:\n    data: \"a\"b\"\n",
+			wantErr: "ping.yml:4: did not find expected key\n    4 |     data: \"a\"b\"",
+		},
+		{
+			name:    "Duplicate key",
+			content: "---\n- name: Configure ping\n  ansible.builtin.ping:\n    data: x\n    data: y\n",
+			wantErr: "ping.yml:5: duplicate key \"data\", first defined on line 4\n    5 |     data: y",
+		},
+		{
+			name:    "Not a list",
+			content: "---\nname: Configure ping\n",
+			wantErr: "ping.yml:2: expected a list of tasks",
+		},
+		{
+			name:    "Unnamed task",
+			content: "---\n- ansible.builtin.ping:\n    data: x\n",
+			wantErr: "ping.yml:2: task has no name",
+		},
+		{
+			name:    "Missing module",
+			content: "---\n- name: Configure ping\n  ping:\n    data: x\n",
+			wantErr: "ping.yml: no task calls ansible.builtin.ping",
+		},
+		{
+			name:    "Options not a mapping",
+			content: "---\n- name: Configure ping\n  ansible.builtin.ping: data=x\n",
+			wantErr: "ping.yml:3: expected the options of ansible.builtin.ping to be a mapping",
+		},
+		{
+			name:    "Several documents",
+			content: "---\n- name: Configure ping\n  ansible.builtin.ping: {}\n---\n- name: Again\n",
+			wantErr: "ping.yml:4: expected a single YAML document\n    4 | ---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTask("ping.yml", "ansible.builtin.ping", tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("unexpected error:\ngot  %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMain(t *testing.T) {
+	if err := ValidateMain("main.yml", "---\n"); err != nil {
+		t.Errorf("expected an empty main.yml to be valid, got %v", err)
+	}
+
+	err := ValidateMain("main.yml", "---\n- name: Configure ping\n  ansible.builtin.include_tasks:\n    apply: {}\n")
+	if err == nil || !strings.Contains(err.Error(), "main.yml:4: include_tasks has no file") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGenerateTask_InvalidYAML(t *testing.T) {
+	doc := &atcgModules.ModuleDoc{
+		Options: map[string]atcgModules.ModuleOption{"msg": {Default: `say "hi"`}},
+	}
+
+	_, err := GenerateTask("ansible.builtin.debug", doc, Options{})
+
+	var yamlErr *InvalidYAMLError
+	if !errors.As(err, &yamlErr) {
+		t.Fatalf("expected an InvalidYAMLError, got %v", err)
+	}
+	if yamlErr.File != "debug.yml" || yamlErr.Line != 5 || yamlErr.Source != `    msg: "{{ item.msg | default('say "hi"') }}"` {
+		t.Errorf("unexpected error %+v", yamlErr)
+	}
+}
@@ -1,19 +1,17 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
-
-	"github.com/spf13/pflag"
 )
 
 // ValidateInputs checks if the modules slice is empty.
-func ValidateInputs(modules []string) {
+func ValidateInputs(modules []string) error {
 	if len(modules) == 0 {
-		fmt.Println("Error: No modules specified. Use -m or --module to specify modules.")
-		pflag.Usage()
-		os.Exit(1)
+		return errors.New("no modules specified, use -m or --module to specify modules")
 	}
+	return nil
 }
 
 // EnsureOutputDirectory creates the output directory if it doesn't exist.
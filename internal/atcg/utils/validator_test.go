@@ -9,13 +9,9 @@ import (
 )
 
 func TestValidateInputs_ValidModules(t *testing.T) {
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("unexpected panic: %v", r)
-		}
-	}()
-
-	ValidateInputs([]string{"module1", "module2"}) // Should not exit
+	if err := ValidateInputs([]string{"module1", "module2"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
 }
 
 func TestEnsureOutputDirectory_Success(t *testing.T) {
@@ -44,15 +40,9 @@ func runSubprocessTest(t *testing.T, testFuncName string) (string, error) {
 }
 
 func TestValidateInputs_EmptyModules(t *testing.T) {
-	if os.Getenv("SUBPROCESS_TEST") == "1" {
-		ValidateInputs([]string{}) // This should call os.Exit
-		return
-	}
-
-	output, err := runSubprocessTest(t, "TestValidateInputs_EmptyModules")
-
-	if err == nil || !strings.Contains(output, "Error: No modules specified") {
-		t.Fatalf("expected os.Exit with error, got: %v, output: %s", err, output)
+	err := ValidateInputs([]string{})
+	if err == nil || !strings.Contains(err.Error(), "no modules specified") {
+		t.Fatalf("expected an error, got: %v", err)
 	}
 }
 